@@ -0,0 +1,212 @@
+package access
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Access modes, set with ACCESS_MODE
+const (
+	ModeOpen      = "open"      // Everyone can use the bot
+	ModeAllowlist = "allowlist" // Only listed users and chats
+	ModeDenylist  = "denylist"  // Everyone except listed users and chats
+	ModeInvite    = "invite"    // Allowlist that users join with invite codes
+)
+
+// Lists
+const (
+	Allow = "access:allow"
+	Deny  = "access:deny"
+)
+
+// redeemScript takes one use of an invite code. Returns the uses left,
+// or -1 if the code doesn't exist. DECR keeps the key's TTL.
+var redeemScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+local left = redis.call('DECR', KEYS[1])
+if left <= 0 then
+	redis.call('DEL', KEYS[1])
+	return 0
+end
+return left
+`)
+
+// Invite is an invite code that adds its users to the allowlist
+type Invite struct {
+	Code      string
+	UsesLeft  int
+	ExpiresIn time.Duration
+}
+
+// Store keeps access lists and invite codes in Redis
+type Store struct {
+	client *redis.Client
+	ctx    context.Context
+	mode   string
+}
+
+// NewStore creates an access store for the mode. client may be nil, then
+// the lists are empty and only admins pass restricted modes.
+func NewStore(client *redis.Client, mode string) *Store {
+	return &Store{
+		client: client,
+		ctx:    context.Background(),
+		mode:   mode,
+	}
+}
+
+// Mode returns the access mode
+func (s *Store) Mode() string {
+	return s.mode
+}
+
+// Allowed reports whether any of the IDs (user, chat) may use the bot
+func (s *Store) Allowed(ids ...int64) bool {
+	switch s.mode {
+	case ModeAllowlist, ModeInvite:
+		for _, id := range ids {
+			if s.Contains(Allow, id) {
+				return true
+			}
+		}
+		return false
+	case ModeDenylist:
+		for _, id := range ids {
+			if s.Contains(Deny, id) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Contains reports whether the list has the ID
+func (s *Store) Contains(list string, id int64) bool {
+	if s.client == nil {
+		return false
+	}
+	return s.client.SIsMember(s.ctx, list, id).Val()
+}
+
+// Add puts the ID on the list. Returns false if it's already there.
+func (s *Store) Add(list string, id int64) (bool, error) {
+	if s.client == nil {
+		return false, fmt.Errorf("access store is not available")
+	}
+	added, err := s.client.SAdd(s.ctx, list, id).Result()
+	return added > 0, err
+}
+
+// Remove takes the ID off the list. Returns false if it wasn't there.
+func (s *Store) Remove(list string, id int64) (bool, error) {
+	if s.client == nil {
+		return false, fmt.Errorf("access store is not available")
+	}
+	removed, err := s.client.SRem(s.ctx, list, id).Result()
+	return removed > 0, err
+}
+
+// List returns the list's IDs in ascending order
+func (s *Store) List(list string) ([]int64, error) {
+	if s.client == nil {
+		return nil, nil
+	}
+	values, err := s.client.SMembers(s.ctx, list).Result()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(values))
+	for _, v := range values {
+		if id, err := strconv.ParseInt(v, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func inviteKey(code string) string {
+	return "invite:" + code
+}
+
+// CreateInvite makes a code valid for the given number of uses and time
+func (s *Store) CreateInvite(uses int, ttl time.Duration) (*Invite, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("access store is not available")
+	}
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	// Lowercase base32 fits Telegram's deep link alphabet
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(buf))
+	if err := s.client.Set(s.ctx, inviteKey(code), uses, ttl).Err(); err != nil {
+		return nil, err
+	}
+	return &Invite{Code: code, UsesLeft: uses, ExpiresIn: ttl}, nil
+}
+
+// RevokeInvite deletes the code. Returns false if it didn't exist.
+func (s *Store) RevokeInvite(code string) (bool, error) {
+	if s.client == nil {
+		return false, fmt.Errorf("access store is not available")
+	}
+	deleted, err := s.client.Del(s.ctx, inviteKey(strings.ToLower(code))).Result()
+	return deleted > 0, err
+}
+
+// Invites returns the active codes
+func (s *Store) Invites() ([]*Invite, error) {
+	var invites []*Invite
+	if s.client == nil {
+		return invites, nil
+	}
+
+	iter := s.client.Scan(s.ctx, 0, inviteKey("*"), 100).Iterator()
+	for iter.Next(s.ctx) {
+		key := iter.Val()
+		uses, err := s.client.Get(s.ctx, key).Int()
+		if err != nil {
+			continue // Used up or expired meanwhile
+		}
+		invites = append(invites, &Invite{
+			Code:      strings.TrimPrefix(key, inviteKey("")),
+			UsesLeft:  uses,
+			ExpiresIn: s.client.TTL(s.ctx, key).Val(),
+		})
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(invites, func(i, j int) bool { return invites[i].ExpiresIn < invites[j].ExpiresIn })
+	return invites, nil
+}
+
+// Redeem uses the code to put the user on the allowlist. Returns false if
+// the code is unknown, used up or expired.
+func (s *Store) Redeem(code string, userID int64) (bool, error) {
+	if s.client == nil {
+		return false, nil
+	}
+	left, err := redeemScript.Run(s.ctx, s.client, []string{inviteKey(strings.ToLower(code))}).Int()
+	if err != nil {
+		return false, err
+	}
+	if left < 0 {
+		return false, nil
+	}
+	if _, err := s.Add(Allow, userID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,253 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"envedour-bot/internal/access"
+	"envedour-bot/internal/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const maxInviteCodeLen = 64 // Telegram's limit for deep link parameters
+
+// checkAccess reports whether the update may be handled in the current
+// ACCESS_MODE. Admins always pass. Users rejected in a private chat are
+// told how to get access.
+func (b *Bot) checkAccess(update tgbotapi.Update, lang string) bool {
+	from := update.SentFrom()
+	if from == nil || b.isAdmin(from.ID) {
+		return true
+	}
+
+	// A listed group lets all its members in
+	ids := []int64{from.ID}
+	if chat := updateChat(update); chat != nil && chat.ID != from.ID {
+		ids = append(ids, chat.ID)
+	}
+	if b.access.Allowed(ids...) {
+		return true
+	}
+
+	text := i18n.T(lang, "access.denied")
+	switch b.access.Mode() {
+	case access.ModeAllowlist:
+		text = i18n.T(lang, "access.allowlist", from.ID)
+	case access.ModeInvite:
+		if ok, text := b.redeemInvite(update.Message, lang); ok || text != "" {
+			b.sendMessage(update.Message.Chat.ID, text)
+			return ok
+		}
+		text = i18n.T(lang, "access.invite_only")
+	}
+	b.reject(update, text)
+	return false
+}
+
+// updateChat returns the chat of a message or a button press
+func updateChat(update tgbotapi.Update) *tgbotapi.Chat {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return update.CallbackQuery.Message.Chat
+	}
+	return nil
+}
+
+// redeemInvite handles "/start <code>" in a private chat. Returns whether
+// the user got access and the reply, which is empty if the message
+// doesn't carry a code.
+func (b *Bot) redeemInvite(msg *tgbotapi.Message, lang string) (bool, string) {
+	if msg == nil || !msg.Chat.IsPrivate() || msg.Command() != "start" {
+		return false, ""
+	}
+	code := strings.TrimSpace(msg.CommandArguments())
+	if code == "" {
+		return false, ""
+	}
+
+	if len(code) <= maxInviteCodeLen {
+		ok, err := b.access.Redeem(code, msg.From.ID)
+		if err != nil {
+			return false, i18n.T(lang, "access.invite_check_failed")
+		}
+		if ok {
+			return true, i18n.T(lang, "access.invite_accepted")
+		}
+	}
+	return false, i18n.T(lang, "access.invite_invalid")
+}
+
+// reject tells the sender why nothing happens. Group messages and inline
+// queries are ignored silently.
+func (b *Bot) reject(update tgbotapi.Update, text string) {
+	switch {
+	case update.CallbackQuery != nil:
+		b.api.Request(tgbotapi.NewCallbackWithAlert(update.CallbackQuery.ID, text))
+	case update.Message != nil && update.Message.Chat.IsPrivate():
+		b.sendMessage(update.Message.Chat.ID, text)
+	}
+}
+
+// handleAccessCommand runs admin commands managing access lists and
+// invites. Returns false if the command isn't one of them.
+func (b *Bot) handleAccessCommand(command string, args []string, lang string) (string, bool) {
+	switch command {
+	case "access":
+		return b.accessStatus(lang), true
+	case "allow":
+		return b.changeList(access.Allow, true, command, args, lang), true
+	case "disallow":
+		return b.changeList(access.Allow, false, command, args, lang), true
+	case "deny":
+		return b.changeList(access.Deny, true, command, args, lang), true
+	case "undeny":
+		return b.changeList(access.Deny, false, command, args, lang), true
+	case "invite":
+		return b.createInvite(args, lang), true
+	case "invites":
+		return b.listInvites(lang), true
+	case "uninvite":
+		return b.revokeInvite(args, lang), true
+	}
+	return "", false
+}
+
+// listInEffect reports whether the list is used in the current mode
+func (b *Bot) listInEffect(list string) bool {
+	switch b.access.Mode() {
+	case access.ModeAllowlist, access.ModeInvite:
+		return list == access.Allow
+	case access.ModeDenylist:
+		return list == access.Deny
+	}
+	return false
+}
+
+func (b *Bot) accessStatus(lang string) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "admin.access_mode", b.access.Mode()))
+	for _, l := range []struct{ name, list string }{
+		{i18n.T(lang, "admin.allowed"), access.Allow},
+		{i18n.T(lang, "admin.denied"), access.Deny},
+	} {
+		ids, err := b.access.List(l.list)
+		if err != nil {
+			return i18n.T(lang, "admin.lists_failed", err)
+		}
+		sb.WriteString(fmt.Sprintf("\n%s (%d)", l.name, len(ids)))
+		if !b.listInEffect(l.list) {
+			sb.WriteString(i18n.T(lang, "admin.list_inactive"))
+		}
+		sb.WriteString(":\n")
+		for _, id := range ids {
+			sb.WriteString(fmt.Sprintf("%d\n", id))
+		}
+	}
+	sb.WriteString(i18n.T(lang, "admin.access_commands"))
+	return sb.String()
+}
+
+func (b *Bot) changeList(list string, add bool, command string, args []string, lang string) string {
+	usage := i18n.T(lang, "admin.list_usage", command)
+	if len(args) != 1 {
+		return usage
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return usage
+	}
+
+	var changed bool
+	if add {
+		changed, err = b.access.Add(list, id)
+	} else {
+		changed, err = b.access.Remove(list, id)
+	}
+	if err != nil {
+		return i18n.T(lang, "admin.list_change_failed", err)
+	}
+
+	name := i18n.T(lang, "admin.allowlist")
+	if list == access.Deny {
+		name = i18n.T(lang, "admin.denylist")
+	}
+	var text string
+	switch {
+	case add && changed:
+		text = i18n.T(lang, "admin.list_added", id, name)
+	case add:
+		text = i18n.T(lang, "admin.list_already", id, name)
+	case changed:
+		text = i18n.T(lang, "admin.list_removed", id, name)
+	default:
+		text = i18n.T(lang, "admin.list_missing", id, name)
+	}
+	if !b.listInEffect(list) {
+		text += i18n.T(lang, "admin.list_unused", b.access.Mode())
+	}
+	return text
+}
+
+func (b *Bot) createInvite(args []string, lang string) string {
+	if len(args) > 2 {
+		return i18n.T(lang, "admin.invite_usage")
+	}
+	uses, days := 1, 7
+	var err error
+	if len(args) > 0 {
+		if uses, err = strconv.Atoi(args[0]); err != nil || uses <= 0 {
+			return i18n.T(lang, "admin.invite_usage")
+		}
+	}
+	if len(args) > 1 {
+		if days, err = strconv.Atoi(args[1]); err != nil || days <= 0 {
+			return i18n.T(lang, "admin.invite_usage")
+		}
+	}
+
+	invite, err := b.access.CreateInvite(uses, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		return i18n.T(lang, "admin.invite_failed", err)
+	}
+	text := i18n.T(lang, "admin.invite_created", uses, days, b.api.Self.UserName, invite.Code, invite.Code)
+	if b.access.Mode() != access.ModeInvite {
+		text += i18n.T(lang, "admin.invite_mode", b.access.Mode())
+	}
+	return text
+}
+
+func (b *Bot) listInvites(lang string) string {
+	invites, err := b.access.Invites()
+	if err != nil {
+		return i18n.T(lang, "admin.invites_failed", err)
+	}
+	if len(invites) == 0 {
+		return i18n.T(lang, "admin.no_invites")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "admin.invites", len(invites)))
+	for _, inv := range invites {
+		sb.WriteString(i18n.T(lang, "admin.invite_line", inv.Code, inv.UsesLeft, formatWait(lang, inv.ExpiresIn)))
+	}
+	return sb.String()
+}
+
+func (b *Bot) revokeInvite(args []string, lang string) string {
+	if len(args) != 1 {
+		return i18n.T(lang, "admin.uninvite_usage")
+	}
+	revoked, err := b.access.RevokeInvite(args[0])
+	if err != nil {
+		return i18n.T(lang, "admin.uninvite_failed", err)
+	}
+	if !revoked {
+		return i18n.T(lang, "admin.invite_unknown")
+	}
+	return i18n.T(lang, "admin.invite_revoked")
+}
@@ -0,0 +1,410 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"envedour-bot/internal/donor"
+	"envedour-bot/internal/i18n"
+	"envedour-bot/internal/queue"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// queueListLimit is how many jobs /queue shows
+const queueListLimit = 20
+
+// isAdmin reports whether the user is listed in ADMIN_CHAT_IDS
+func (b *Bot) isAdmin(userID int64) bool {
+	for _, id := range b.config.AdminChatIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// handleAdminCommand runs admin-only commands. Returns false if the command
+// isn't one of them or the sender isn't an admin, so it's handled as usual.
+func (b *Bot) handleAdminCommand(msg *tgbotapi.Message, lang string) bool {
+	if msg.From == nil || !b.isAdmin(msg.From.ID) {
+		return false
+	}
+
+	chatID := msg.Chat.ID
+	args := strings.Fields(msg.CommandArguments())
+	if text, ok := b.handleAccessCommand(msg.Command(), args, lang); ok {
+		b.sendMessage(chatID, text)
+		return true
+	}
+	switch msg.Command() {
+	case "grant":
+		b.sendMessage(chatID, b.grantDonor(msg.From.ID, args, lang))
+	case "revoke":
+		b.sendMessage(chatID, b.revokeDonor(args, lang))
+	case "donors":
+		b.sendMessage(chatID, b.listDonors(lang))
+	case "admin":
+		b.sendMessage(chatID, i18n.T(lang, "admin.help"))
+	case "stats":
+		b.sendMessage(chatID, b.stats(lang))
+	case "queue":
+		b.sendMessage(chatID, b.listQueue(lang))
+	case "drop":
+		b.sendMessage(chatID, b.dropJob(args, lang))
+	case "prio":
+		b.sendMessage(chatID, b.reprioritizeJob(args, lang))
+	case "flush":
+		b.sendMessage(chatID, b.flushQueue(lang))
+	case "workers":
+		b.sendMessage(chatID, b.listWorkers(lang))
+	case "ban":
+		b.sendMessage(chatID, b.banUser(args, lang))
+	case "unban":
+		b.sendMessage(chatID, b.unbanUser(args, lang))
+	case "banned":
+		b.sendMessage(chatID, b.listBanned(lang))
+	case "broadcast":
+		b.sendMessage(chatID, b.startBroadcast(msg, lang))
+	default:
+		return false
+	}
+	return true
+}
+
+func (b *Bot) grantDonor(adminID int64, args []string, lang string) string {
+	if len(args) == 0 || len(args) > 3 {
+		return i18n.T(lang, "admin.grant_usage")
+	}
+	chatID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return i18n.T(lang, "admin.grant_usage")
+	}
+
+	duration := 30 * 24 * time.Hour
+	if len(args) > 1 {
+		if args[1] == "forever" {
+			duration = 0
+		} else {
+			days, err := strconv.Atoi(args[1])
+			if err != nil || days <= 0 {
+				return i18n.T(lang, "admin.grant_usage")
+			}
+			duration = time.Duration(days) * 24 * time.Hour
+		}
+	}
+
+	tier := donor.TierBasic
+	if len(args) > 2 {
+		tier = args[2]
+		if !donor.ValidTier(tier) {
+			return i18n.T(lang, "admin.grant_usage")
+		}
+	}
+
+	d, err := b.donors.Grant(chatID, tier, duration, adminID)
+	if err != nil {
+		return i18n.T(lang, "admin.grant_failed", err)
+	}
+	donorLang := b.chatLang(chatID)
+	b.sendMessage(chatID, i18n.T(donorLang, "donor.granted", formatDonorExpiry(donorLang, d)))
+	text := fmt.Sprintf("✅ %d: %s %s", chatID, d.Tier, formatDonorExpiry(lang, d))
+	if d.Static {
+		text += " (DONOR_CHAT_IDS)"
+	}
+	return text
+}
+
+func (b *Bot) revokeDonor(args []string, lang string) string {
+	if len(args) != 1 {
+		return i18n.T(lang, "admin.revoke_usage")
+	}
+	chatID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return i18n.T(lang, "admin.revoke_usage")
+	}
+
+	d, _ := b.donors.Get(chatID)
+	revoked, err := b.donors.Revoke(chatID)
+	if err != nil {
+		return i18n.T(lang, "admin.revoke_failed", err)
+	}
+	// Static donors only lose what was granted on top of DONOR_CHAT_IDS
+	if d != nil && d.Static {
+		if revoked {
+			return i18n.T(lang, "admin.revoked_static", chatID)
+		}
+		return i18n.T(lang, "admin.revoke_static")
+	}
+	if !revoked {
+		return i18n.T(lang, "admin.not_donor", chatID)
+	}
+	return i18n.T(lang, "admin.revoked", chatID)
+}
+
+func (b *Bot) listDonors(lang string) string {
+	donors, err := b.donors.List()
+	if err != nil {
+		return i18n.T(lang, "admin.list_failed", err)
+	}
+	if len(donors) == 0 {
+		return i18n.T(lang, "admin.no_donors")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "admin.donors", len(donors)))
+	for _, d := range donors {
+		line := fmt.Sprintf("\n%d — %s, %s", d.ChatID, d.Tier, formatDonorExpiry(lang, d))
+		if d.Static {
+			line += " (DONOR_CHAT_IDS)"
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+func (b *Bot) stats(lang string) string {
+	jobs, err := b.queue.Jobs()
+	if err != nil {
+		return i18n.T(lang, "admin.queue_failed", err)
+	}
+	high := 0
+	for _, job := range jobs {
+		if job.Priority == queue.PriorityHigh {
+			high++
+		}
+	}
+
+	busy := 0
+	workers := b.executor.Workers()
+	for _, w := range workers {
+		if w.Job != nil {
+			busy++
+		}
+	}
+
+	donors, _ := b.donors.List()
+	banned, _ := b.users.Banned()
+
+	text := i18n.T(lang, "admin.stats",
+		b.users.KnownCount(), len(donors), len(banned),
+		len(jobs), high, busy, len(workers), formatWait(lang, time.Since(b.started)))
+	if b.executor.IsThrottled() {
+		text += i18n.T(lang, "admin.overheated")
+	}
+	return text
+}
+
+func (b *Bot) listQueue(lang string) string {
+	jobs, err := b.queue.Jobs()
+	if err != nil {
+		return i18n.T(lang, "admin.queue_failed", err)
+	}
+	if len(jobs) == 0 {
+		return i18n.T(lang, "admin.queue_empty")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "admin.queue", len(jobs)))
+	for i, job := range jobs {
+		if i == queueListLimit {
+			sb.WriteString(i18n.T(lang, "admin.queue_more", len(jobs)-queueListLimit))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("\n%d. %s\n", i+1, formatJob(job, lang)))
+	}
+	return sb.String()
+}
+
+// formatJob renders a job for admin listings
+func formatJob(job *queue.Job, lang string) string {
+	priority := "🐢"
+	if job.Priority == queue.PriorityHigh {
+		priority = "⚡"
+	}
+	url := job.URL
+	if len(url) > 80 {
+		url = url[:77] + "..."
+	}
+	line := fmt.Sprintf("%s %s — %d, %s %s", priority, job.ID, job.ChatID, job.MediaType, job.Quality)
+	if !job.CreatedAt.IsZero() {
+		line += ", " + i18n.T(lang, "admin.job_age", formatWait(lang, time.Since(job.CreatedAt)))
+	}
+	return line + "\n" + url
+}
+
+func (b *Bot) dropJob(args []string, lang string) string {
+	if len(args) != 1 {
+		return i18n.T(lang, "admin.drop_usage")
+	}
+	job, err := b.queue.Remove(args[0])
+	if err != nil {
+		return i18n.T(lang, "admin.drop_failed", err)
+	}
+	if job == nil {
+		return i18n.T(lang, "admin.job_not_queued")
+	}
+	b.notifyCancelled([]*queue.Job{job})
+	return i18n.T(lang, "admin.job_dropped", formatJob(job, lang))
+}
+
+func (b *Bot) reprioritizeJob(args []string, lang string) string {
+	usage := i18n.T(lang, "admin.prio_usage")
+	if len(args) != 2 {
+		return usage
+	}
+	var priority queue.Priority
+	switch args[1] {
+	case "high":
+		priority = queue.PriorityHigh
+	case "low":
+		priority = queue.PriorityLow
+	default:
+		return usage
+	}
+
+	job, err := b.queue.SetPriority(args[0], priority)
+	if err != nil {
+		return i18n.T(lang, "admin.prio_failed", err)
+	}
+	if job == nil {
+		return i18n.T(lang, "admin.job_not_queued")
+	}
+	return i18n.T(lang, "admin.prio_changed", formatJob(job, lang))
+}
+
+func (b *Bot) flushQueue(lang string) string {
+	jobs, err := b.queue.Flush()
+	if err != nil {
+		return i18n.T(lang, "admin.flush_failed", err)
+	}
+	if len(jobs) == 0 {
+		return i18n.T(lang, "admin.flush_empty")
+	}
+	b.notifyCancelled(jobs)
+	return i18n.T(lang, "admin.flushed", len(jobs))
+}
+
+// notifyCancelled tells the owners of dropped jobs, once per chat
+func (b *Bot) notifyCancelled(jobs []*queue.Job) {
+	notified := make(map[int64]bool)
+	for _, job := range jobs {
+		if job.InlineMessage != "" {
+			b.editInlineText(job.InlineMessage, i18n.T(job.Lang, "admin.inline_cancelled"))
+			continue
+		}
+		if notified[job.ChatID] {
+			continue
+		}
+		notified[job.ChatID] = true
+		b.sendMessage(job.ChatID, i18n.T(job.Lang, "admin.jobs_cancelled"))
+	}
+}
+
+func (b *Bot) listWorkers(lang string) string {
+	workers := b.executor.Workers()
+	if len(workers) == 0 {
+		return i18n.T(lang, "admin.no_workers")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "admin.workers", len(workers)))
+	for _, w := range workers {
+		since := formatWait(lang, time.Since(w.Since))
+		if w.Job == nil {
+			sb.WriteString(i18n.T(lang, "admin.worker_idle", w.ID, since))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n#%d ⏳ %s\n%s", w.ID, since, formatJob(w.Job, lang)))
+	}
+	if b.executor.IsThrottled() {
+		sb.WriteString(i18n.T(lang, "admin.overheated"))
+	}
+	return sb.String()
+}
+
+// isBanned reports whether updates from the user are ignored. Admins
+// can't be banned, so a mistake can always be undone.
+func (b *Bot) isBanned(userID int64) bool {
+	return !b.isAdmin(userID) && b.users.IsBanned(userID)
+}
+
+func (b *Bot) banUser(args []string, lang string) string {
+	if len(args) != 1 {
+		return i18n.T(lang, "admin.ban_usage")
+	}
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return i18n.T(lang, "admin.ban_usage")
+	}
+	if b.isAdmin(userID) {
+		return i18n.T(lang, "admin.ban_admin")
+	}
+
+	banned, err := b.users.Ban(userID)
+	if err != nil {
+		return i18n.T(lang, "admin.ban_failed", err)
+	}
+	if !banned {
+		return i18n.T(lang, "admin.already_banned", userID)
+	}
+
+	// Queued jobs of the user won't be processed either
+	dropped := 0
+	jobs, _ := b.queue.Jobs()
+	for _, job := range jobs {
+		if job.ChatID != userID {
+			continue
+		}
+		if removed, _ := b.queue.Remove(job.ID); removed != nil {
+			dropped++
+		}
+	}
+	return i18n.T(lang, "admin.banned", userID, dropped)
+}
+
+func (b *Bot) unbanUser(args []string, lang string) string {
+	if len(args) != 1 {
+		return i18n.T(lang, "admin.unban_usage")
+	}
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return i18n.T(lang, "admin.unban_usage")
+	}
+
+	unbanned, err := b.users.Unban(userID)
+	if err != nil {
+		return i18n.T(lang, "admin.unban_failed", err)
+	}
+	if !unbanned {
+		return i18n.T(lang, "admin.not_banned", userID)
+	}
+	return i18n.T(lang, "admin.unbanned", userID)
+}
+
+func (b *Bot) listBanned(lang string) string {
+	banned, err := b.users.Banned()
+	if err != nil {
+		return i18n.T(lang, "admin.list_failed", err)
+	}
+	if len(banned) == 0 {
+		return i18n.T(lang, "admin.no_banned")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "admin.banned_list", len(banned)))
+	for _, id := range banned {
+		sb.WriteString(fmt.Sprintf("\n%d", id))
+	}
+	return sb.String()
+}
+
+// formatDonorExpiry renders "forever" or "until 02.01.2006 15:04" in the language
+func formatDonorExpiry(lang string, d *donor.Donor) string {
+	if d.Permanent() {
+		return i18n.T(lang, "donor.forever")
+	}
+	return i18n.T(lang, "donor.until", d.ExpiresAt.Format("02.01.2006 15:04"))
+}
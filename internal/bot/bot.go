@@ -3,12 +3,20 @@ package bot
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"envedour-bot/internal/access"
 	"envedour-bot/internal/config"
+	"envedour-bot/internal/donor"
 	"envedour-bot/internal/executor"
+	"envedour-bot/internal/extractor"
+	"envedour-bot/internal/history"
+	"envedour-bot/internal/i18n"
 	"envedour-bot/internal/queue"
+	"envedour-bot/internal/ratelimit"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -20,6 +28,16 @@ type Bot struct {
 	executor    *executor.Executor
 	workerPool  chan struct{}
 	preferences *PreferencesStore
+	limiter     *ratelimit.Limiter
+	donors      *donor.Store
+	users       *UserStore
+	access      *access.Store
+	history     *history.Store
+
+	started      time.Time
+	broadcasting atomic.Bool
+
+	inlineSearches chan struct{} // Limits inline searches running outside workerPool
 }
 
 func NewBot(cfg *config.Config, q queue.Queue, exec *executor.Executor) (*Bot, error) {
@@ -45,8 +63,11 @@ func NewBot(cfg *config.Config, q queue.Queue, exec *executor.Executor) (*Bot, e
 
 	// Initialize preferences store
 	var prefsStore *PreferencesStore
-	if redisClient := q.GetClient(); redisClient != nil {
+	var limiter *ratelimit.Limiter
+	redisClient := q.GetClient()
+	if redisClient != nil {
 		prefsStore = NewPreferencesStore(redisClient)
+		limiter = ratelimit.New(redisClient)
 	}
 
 	bot := &Bot{
@@ -55,7 +76,15 @@ func NewBot(cfg *config.Config, q queue.Queue, exec *executor.Executor) (*Bot, e
 		queue:       q,
 		executor:    exec,
 		preferences: prefsStore,
+		limiter:     limiter,
+		donors:      donor.NewStore(redisClient, cfg.DonorChatIDs),
+		users:       NewUserStore(redisClient),
+		access:      access.NewStore(redisClient, cfg.AccessMode),
+		history:     history.New(redisClient),
+		started:     time.Now(),
 		workerPool:  make(chan struct{}, cfg.WorkerCount+2),
+
+		inlineSearches: make(chan struct{}, maxInlineSearches),
 	}
 
 	return bot, nil
@@ -81,9 +110,41 @@ func (b *Bot) Start(ctx context.Context) {
 func (b *Bot) handleUpdate(update tgbotapi.Update) {
 	defer func() { <-b.workerPool }() // Release worker
 
+	lang := b.updateLang(update)
+
+	// Payments skip the ban and access checks: once the payment arrives
+	// the Stars are charged already and dropping it would lose them.
+	// Pre-checkout follows the same path so a started payment can finish.
+	if update.PreCheckoutQuery != nil {
+		b.handlePreCheckout(update.PreCheckoutQuery, lang)
+		return
+	}
+	if msg := update.Message; msg != nil && msg.SuccessfulPayment != nil {
+		b.handleSuccessfulPayment(msg, lang)
+		return
+	}
+
+	if from := update.SentFrom(); from != nil && b.isBanned(from.ID) {
+		b.reject(update, i18n.T(lang, "access.banned"))
+		return
+	}
+	if !b.checkAccess(update, lang) {
+		return
+	}
+
 	// Handle callback queries (button presses)
 	if update.CallbackQuery != nil {
-		b.handleCallbackQuery(update.CallbackQuery)
+		b.handleCallbackQuery(update.CallbackQuery, lang)
+		return
+	}
+
+	// Handle inline mode
+	if update.InlineQuery != nil {
+		b.handleInlineQuery(update.InlineQuery, lang)
+		return
+	}
+	if update.ChosenInlineResult != nil {
+		b.handleChosenInlineResult(update.ChosenInlineResult, lang)
 		return
 	}
 
@@ -94,98 +155,143 @@ func (b *Bot) handleUpdate(update tgbotapi.Update) {
 	msg := update.Message
 	chatID := msg.Chat.ID
 
-	// Check if user is donor
-	isDonor := b.isDonor(chatID)
+	if msg.Chat.IsPrivate() {
+		b.users.Remember(chatID)
+	}
+
+	if isGroup(msg.Chat) {
+		b.handleGroupMessage(msg, lang)
+		return
+	}
 
 	switch {
 	case msg.IsCommand():
-		b.handleCommand(msg, isDonor)
-	case msg.Text != "":
-		b.handleURL(msg, isDonor)
+		b.handleCommand(msg, lang)
+	case msg.Text != "" || msg.Caption != "" || msg.ReplyToMessage != nil:
+		b.handleURL(msg, lang)
 	}
 }
 
-func (b *Bot) handleCommand(msg *tgbotapi.Message, isDonor bool) {
+func (b *Bot) handleCommand(msg *tgbotapi.Message, lang string) {
 	chatID := msg.Chat.ID
 	command := msg.Command()
 
+	if b.handleAdminCommand(msg, lang) {
+		return
+	}
+
 	switch command {
 	case "start":
-		helpText := "Привет! Я бот для скачивания видео.\n\n" +
-			"📥 Отправь ссылку на видео для скачивания\n\n" +
-			"Используй кнопки ниже для настройки:"
-		msg := tgbotapi.NewMessage(chatID, helpText)
-		msg.ReplyMarkup = createMainKeyboard()
-		b.api.Send(msg)
+		// Sent from the inline mode hint
+		if msg.CommandArguments() == "subscribe" && !b.requireSubscription(msg, lang) {
+			return
+		}
+		helpText := i18n.T(lang, "cmd.start")
+		if isGroup(msg.Chat) {
+			helpText += i18n.T(lang, "cmd.start_group")
+		}
+		reply := tgbotapi.NewMessage(chatID, helpText)
+		reply.ReplyMarkup = b.mainKeyboard(msg.Chat, lang)
+		b.api.Send(reply)
 		return
 	case "help":
-		helpText := "📋 Справка:\n\n" +
-			"💡 Просто отправь ссылку на видео для скачивания!\n\n" +
-			"Используй кнопки для настройки качества и типа медиа.\n\n" +
-			"⚙️ Качество - выбери разрешение видео\n" +
-			"🎵 Аудио/Видео - выбери тип скачивания\n" +
-			"📊 Статус - посмотри очередь и настройки"
-		msg := tgbotapi.NewMessage(chatID, helpText)
-		msg.ReplyMarkup = createMainKeyboard()
-		b.api.Send(msg)
+		reply := tgbotapi.NewMessage(chatID, i18n.T(lang, "cmd.help"))
+		reply.ReplyMarkup = b.mainKeyboard(msg.Chat, lang)
+		b.api.Send(reply)
 		return
 	case "status":
-		b.showStatus(chatID)
+		b.showStatus(msg.Chat, lang)
+	case "donate":
+		b.showPriorityPlans(msg, lang)
+	case "history":
+		b.showHistory(chatID, lang)
+	case "lang":
+		b.showLanguages(chatID, lang)
 	case "quality", "audio", "video":
 		// These commands are now handled via inline buttons
 		// Show main menu
-		msg := tgbotapi.NewMessage(chatID, "Используй кнопки для настройки:")
-		msg.ReplyMarkup = createMainKeyboard()
-		b.api.Send(msg)
+		reply := tgbotapi.NewMessage(chatID, i18n.T(lang, "cmd.use_buttons"))
+		reply.ReplyMarkup = b.mainKeyboard(msg.Chat, lang)
+		b.api.Send(reply)
 	default:
-		b.sendMessage(chatID, "❌ Неизвестная команда. Используйте /help")
+		if isGroup(msg.Chat) {
+			return // Commands of other bots look the same
+		}
+		b.sendMessage(chatID, i18n.T(lang, "cmd.unknown"))
 	}
 }
 
-func (b *Bot) handleURL(msg *tgbotapi.Message, isDonor bool) {
-	url := msg.Text
+func (b *Bot) handleURL(msg *tgbotapi.Message, lang string) {
+	text := strings.TrimSpace(msg.Text)
+	if text == "" {
+		text = strings.TrimSpace(msg.Caption)
+	}
+	text = b.stripMention(text)
 	chatID := msg.Chat.ID
 
-	// Validate URL - check if it's a valid URL format
-	// Also check for URL entities in case Telegram parsed it
-	if !isValidURL(url) {
-		// Check if message has URL entities
-		if len(msg.Entities) > 0 {
-			for _, entity := range msg.Entities {
-				if entity.Type == "url" {
-					url = msg.Text[entity.Offset : entity.Offset+entity.Length]
-					break
-				}
-			}
-		}
-		// If still not valid, reject
-		if !isValidURL(url) {
-			b.sendMessage(chatID, "Пожалуйста, отправьте валидную ссылку на видео.")
+	// Stickers and the like sent as a reply, and group chatter
+	// in reply to the bot are ignored
+	urls := extractURLs(msg)
+	if len(urls) == 0 && (text == "" || (isGroup(msg.Chat) && !b.mentionsBot(msg))) {
+		return
+	}
+
+	if limitText, ok := b.allowRequest(chatID, lang); !ok {
+		b.reply(msg, limitText)
+		return
+	}
+	if !b.requireSubscription(msg, lang) {
+		return
+	}
+
+	// Anything typed without a link is a search query
+	if len(urls) == 0 {
+		if msg.Text == "" || msg.ForwardDate != 0 {
+			b.reply(msg, i18n.T(lang, "url.no_links"))
 			return
 		}
+		b.handleSearch(msg, text, lang)
+		return
 	}
-
-	// Check if URL is from Instagram or TikTok - auto-download best quality
-	if isInstagramURL(url) || isTikTokURL(url) {
-		// For Instagram/TikTok always use video (not audio)
-		// Create job with best quality automatically
-		job := &queue.Job{
-			ID:        generateJobID(),
-			URL:       url,
-			ChatID:    chatID,
-			Priority:  queue.PriorityLow,
-			Quality:   "best",
-			MediaType: "video", // Always video for Instagram/TikTok
-			CreatedAt: time.Now(),
+	if len(urls) > 1 {
+		b.enqueueURLs(msg, urls, lang)
+		return
+	}
+	url := urls[0]
+
+	// An optional fragment spec may follow a single link:
+	// "<url> 1:20-2:05". Other text after the link is ignored.
+	rest := ""
+	if fields := strings.Fields(text); len(fields) > 0 && normalizeLink(fields[0]) == url {
+		if spec := strings.TrimSpace(strings.TrimPrefix(text, fields[0])); looksLikeRange(spec) {
+			rest = spec
 		}
+	}
+
+	clipStart, clipEnd, err := parseClipRange(rest, url)
+	if err != nil {
+		b.reply(msg, i18n.T(lang, "url.bad_range"))
+		return
+	}
 
-		if isDonor {
-			job.Priority = queue.PriorityHigh
+	// Canonicalize after the fragment is parsed, ?t= is dropped here
+	url = extractor.Canonicalize(context.Background(), url)
+
+	// Platforms like Instagram/TikTok and direct file links are
+	// auto-downloaded in best quality
+	if extractor.ForURL(url).AutoDownload() || extractor.DirectMediaType(url) != "" {
+		if limitText, ok := b.allowJobs(chatID, 1, lang); !ok {
+			b.reply(msg, limitText)
+			return
 		}
+		job := b.defaultJob(chatID, url, lang)
+		job.ClipStart = clipStart
+		job.ClipEnd = clipEnd
+		job.ReplyTo = replyTarget(msg)
 
 		// Add to queue
 		if err := b.queue.Enqueue(job); err != nil {
-			b.sendMessage(chatID, "Ошибка при добавлении задачи в очередь. Попробуйте позже.")
+			b.reply(msg, i18n.T(lang, "queue.enqueue_failed"))
 			return
 		}
 
@@ -198,17 +304,22 @@ func (b *Bot) handleURL(msg *tgbotapi.Message, isDonor bool) {
 
 	// Save URL temporarily in Redis (will be retrieved when user selects quality)
 	if b.preferences != nil {
-		if err := b.preferences.SavePendingURL(jobID, url); err != nil {
-			b.sendMessage(chatID, "❌ Ошибка при обработке ссылки. Попробуйте позже.")
+		pending := &PendingDownload{URL: url, ClipStart: clipStart, ClipEnd: clipEnd}
+		if err := b.preferences.SavePendingDownload(jobID, pending); err != nil {
+			b.reply(msg, i18n.T(lang, "url.save_failed"))
 			return
 		}
 	}
 
 	// Show quality selection keyboard
-	text := "📥 Выбери качество для скачивания:"
-	keyboard := createDownloadQualityKeyboard(jobID)
-	message := tgbotapi.NewMessage(chatID, text)
+	prompt := i18n.T(lang, "url.choose_quality")
+	if clipStart > 0 || clipEnd > 0 {
+		prompt += i18n.T(lang, "url.fragment", formatClipRange(lang, clipStart, clipEnd))
+	}
+	keyboard := createDownloadQualityKeyboard(lang, jobID)
+	message := tgbotapi.NewMessage(chatID, prompt)
 	message.ReplyMarkup = &keyboard
+	message.ReplyToMessageID = replyTarget(msg)
 	b.api.Send(message)
 }
 
@@ -217,13 +328,49 @@ func (b *Bot) sendMessage(chatID int64, text string) {
 	b.api.Send(msg)
 }
 
-func (b *Bot) isDonor(chatID int64) bool {
-	for _, id := range b.config.DonorChatIDs {
-		if id == chatID {
-			return true
-		}
+// getPreferences returns the user's preferences, or defaults if the store is unavailable
+func (b *Bot) getPreferences(chatID int64) *UserPreferences {
+	if b.preferences != nil {
+		return b.preferences.GetPreferences(chatID)
 	}
-	return false
+	return defaultPreferences()
+}
+
+// newJob creates a job for the chat with donor priority and per-user
+// options taken from preferences. lang is the language the executor
+// answers in.
+func (b *Bot) newJob(chatID int64, url, quality, mediaType, lang string) *queue.Job {
+	prefs := b.getPreferences(chatID)
+	job := &queue.Job{
+		ID:           generateJobID(),
+		URL:          url,
+		ChatID:       chatID,
+		Priority:     queue.PriorityLow,
+		Quality:      quality,
+		MediaType:    mediaType,
+		AudioFormat:  prefs.AudioFormat,
+		AudioBitrate: prefs.AudioBitrate,
+		EmbedTags:    prefs.AudioTags,
+		Delivery:     prefs.delivery(mediaType),
+		SponsorBlock: prefs.SponsorBlock,
+		Lang:         lang,
+		CreatedAt:    time.Now(),
+	}
+
+	if mediaType == "video" && prefs.SubMode != "" {
+		job.SubMode = prefs.SubMode
+		job.SubLang = prefs.SubLang
+		job.SubAuto = prefs.SubAuto
+	}
+
+	if b.isDonor(chatID) {
+		job.Priority = queue.PriorityHigh
+	}
+	return job
+}
+
+func (b *Bot) isDonor(chatID int64) bool {
+	return b.donors.IsDonor(chatID)
 }
 
 func isValidURL(s string) bool {
@@ -239,105 +386,241 @@ func isValidURL(s string) bool {
 	return false
 }
 
-func isInstagramURL(url string) bool {
-	return strings.Contains(url, "instagram.com") || strings.Contains(url, "instagr.am")
-}
-
-func isTikTokURL(url string) bool {
-	return strings.Contains(url, "tiktok.com") || strings.Contains(url, "vt.tiktok.com")
-}
-
 func generateJobID() string {
 	return fmt.Sprintf("job_%d", time.Now().UnixNano())
 }
 
-func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
+func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery, lang string) {
 	chatID := query.Message.Chat.ID
 	data := query.Data
 
+	// Group settings are shared, only admins may change them
+	if !b.canChangeSettings(query) {
+		alert := tgbotapi.NewCallbackWithAlert(query.ID, i18n.T(lang, "group.admins_only"))
+		b.api.Request(alert)
+		return
+	}
+
+	// Answers with its own alert
+	if data == "sub_check" {
+		b.checkSubscriptionAgain(query, lang)
+		return
+	}
+
 	// Answer callback to remove loading state
 	callback := tgbotapi.NewCallback(query.ID, "")
 	b.api.Request(callback)
 
 	switch {
 	case data == "menu_main":
-		keyboard := createMainKeyboard()
-		msg := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, "Главное меню:")
+		keyboard := b.mainKeyboard(query.Message.Chat, lang)
+		msg := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, i18n.T(lang, "menu.main"))
 		msg.ReplyMarkup = &keyboard
 		b.api.Send(msg)
 
 	case data == "menu_quality":
-		var prefs *UserPreferences
-		if b.preferences != nil {
-			prefs = b.preferences.GetPreferences(chatID)
+		prefs := b.getPreferences(chatID)
+		text := i18n.T(lang, "menu.quality", prefs.Quality)
+		keyboard := createQualityKeyboard(lang, prefs)
+		msg := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
+		msg.ReplyMarkup = &keyboard
+		b.api.Send(msg)
+
+	case data == "menu_media":
+		prefs := b.getPreferences(chatID)
+		text := i18n.T(lang, "menu.media", prefs.MediaType)
+		keyboard := createMediaTypeKeyboard(lang)
+		msg := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
+		msg.ReplyMarkup = &keyboard
+		b.api.Send(msg)
+
+	case data == "menu_audio":
+		prefs := b.getPreferences(chatID)
+		text := i18n.T(lang, "menu.audio", formatAudioPrefs(lang, prefs))
+		keyboard := createAudioFormatKeyboard(lang, prefs)
+		msg := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
+		msg.ReplyMarkup = &keyboard
+		b.api.Send(msg)
+
+	case strings.HasPrefix(data, "afmt_"), strings.HasPrefix(data, "abr_"), data == "atags_toggle":
+		if b.preferences == nil {
+			b.sendMessage(chatID, i18n.T(lang, "prefs.unavailable"))
+			return
+		}
+		var err error
+		if data == "atags_toggle" {
+			err = b.preferences.ToggleAudioTags(chatID)
+		} else if format := strings.TrimPrefix(data, "afmt_"); format != data {
+			if !executor.IsAudioFormat(format) {
+				return
+			}
+			err = b.preferences.SetAudioFormat(chatID, format)
+		} else {
+			bitrate := strings.TrimPrefix(data, "abr_")
+			if !executor.IsAudioBitrate(bitrate) {
+				return
+			}
+			err = b.preferences.SetAudioBitrate(chatID, bitrate)
 		}
-		if prefs == nil {
-			prefs = &UserPreferences{Quality: "best", MediaType: "video"}
+		if err != nil {
+			b.sendMessage(chatID, i18n.T(lang, "prefs.save_failed"))
+			return
 		}
-		text := fmt.Sprintf("⚙️ Выбери качество:\n\nТекущее: %s", prefs.Quality)
-		keyboard := createQualityKeyboard()
+		prefs := b.preferences.GetPreferences(chatID)
+		text := i18n.T(lang, "menu.audio_set", formatAudioPrefs(lang, prefs))
+		keyboard := createAudioFormatKeyboard(lang, prefs)
 		msg := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
 		msg.ReplyMarkup = &keyboard
 		b.api.Send(msg)
 
-	case data == "menu_media":
-		var prefs *UserPreferences
-		if b.preferences != nil {
-			prefs = b.preferences.GetPreferences(chatID)
+	case data == "menu_delivery", data == "dlv_voice", data == "dlv_round":
+		if data != "menu_delivery" {
+			if b.preferences == nil {
+				b.sendMessage(chatID, i18n.T(lang, "prefs.unavailable"))
+				return
+			}
+			var err error
+			if data == "dlv_voice" {
+				err = b.preferences.ToggleVoiceAudio(chatID)
+			} else {
+				err = b.preferences.ToggleRoundVideo(chatID)
+			}
+			if err != nil {
+				b.sendMessage(chatID, i18n.T(lang, "prefs.save_failed"))
+				return
+			}
 		}
-		if prefs == nil {
-			prefs = &UserPreferences{Quality: "best", MediaType: "video"}
+		prefs := b.getPreferences(chatID)
+		text := i18n.T(lang, "menu.delivery", b.config.VoiceMaxSeconds, b.config.VideoNoteMaxSeconds)
+		keyboard := createDeliveryKeyboard(lang, prefs)
+		msg := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
+		msg.ReplyMarkup = &keyboard
+		b.api.Send(msg)
+
+	case data == "menu_subs", strings.HasPrefix(data, "sub_"):
+		if data != "menu_subs" {
+			if b.preferences == nil {
+				b.sendMessage(chatID, i18n.T(lang, "prefs.unavailable"))
+				return
+			}
+			prefs := b.preferences.GetPreferences(chatID)
+			var err error
+			switch {
+			case data == "sub_auto":
+				err = b.preferences.ToggleSubAuto(chatID)
+			case strings.HasPrefix(data, "sub_lang_"):
+				mode := prefs.SubMode
+				if mode == "" {
+					// Picking a language implies the user wants subtitles
+					mode = "file"
+				}
+				err = b.preferences.SetSubtitles(chatID, mode, strings.TrimPrefix(data, "sub_lang_"))
+			case data == "sub_mode_off":
+				err = b.preferences.SetSubtitles(chatID, "", "")
+			case data == "sub_mode_file", data == "sub_mode_burn":
+				err = b.preferences.SetSubtitles(chatID, strings.TrimPrefix(data, "sub_mode_"), "")
+			}
+			if err != nil {
+				b.sendMessage(chatID, i18n.T(lang, "prefs.save_failed"))
+				return
+			}
 		}
-		text := fmt.Sprintf("🎵 Выбери тип:\n\nТекущий: %s", prefs.MediaType)
-		keyboard := createMediaTypeKeyboard()
+		prefs := b.getPreferences(chatID)
+		text := i18n.T(lang, "menu.subs", formatSubtitlePrefs(lang, prefs))
+		keyboard := createSubtitlesKeyboard(lang, prefs)
+		msg := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
+		msg.ReplyMarkup = &keyboard
+		b.api.Send(msg)
+
+	case data == "menu_lang":
+		text, keyboard := b.languageMenu(chatID, lang)
 		msg := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
 		msg.ReplyMarkup = &keyboard
 		b.api.Send(msg)
 
+	case strings.HasPrefix(data, "lang_"):
+		b.setLanguage(query, strings.TrimPrefix(data, "lang_"), lang)
+
+	case strings.HasPrefix(data, "buy_"):
+		days, err := strconv.Atoi(strings.TrimPrefix(data, "buy_"))
+		if err != nil {
+			return
+		}
+		b.sendPriorityInvoice(chatID, days, lang)
+
+	case data == "grp_auto_toggle":
+		if b.preferences != nil {
+			b.preferences.ToggleGroupAutoDownload(chatID)
+		}
+		keyboard := b.mainKeyboard(query.Message.Chat, lang)
+		msg := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, i18n.T(lang, "menu.group_auto"))
+		msg.ReplyMarkup = &keyboard
+		b.api.Send(msg)
+
 	case data == "cmd_status":
-		b.showStatus(chatID)
+		b.showStatus(query.Message.Chat, lang)
 		// Delete the button message
 		deleteMsg := tgbotapi.NewDeleteMessage(chatID, query.Message.MessageID)
 		b.api.Send(deleteMsg)
 
 	case strings.HasPrefix(data, "quality_"):
 		if b.preferences == nil {
-			b.sendMessage(chatID, "❌ Система предпочтений недоступна")
+			b.sendMessage(chatID, i18n.T(lang, "prefs.unavailable"))
 			return
 		}
 		quality := strings.TrimPrefix(data, "quality_")
 		if err := b.preferences.SetQuality(chatID, quality); err != nil {
-			b.sendMessage(chatID, "❌ Ошибка при сохранении настроек")
+			b.sendMessage(chatID, i18n.T(lang, "prefs.save_failed"))
 			return
 		}
-		mediaType := "видео"
+		mediaType := i18n.T(lang, "media.video")
 		if quality == "audio" {
-			mediaType = "аудио"
+			mediaType = i18n.T(lang, "media.audio")
 		}
-		text := fmt.Sprintf("✅ Качество установлено: %s (%s)", quality, mediaType)
-		keyboard := createQualityKeyboard()
+		text := i18n.T(lang, "menu.quality_set", quality, mediaType)
+		keyboard := createQualityKeyboard(lang, b.preferences.GetPreferences(chatID))
+		msg := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
+		msg.ReplyMarkup = &keyboard
+		b.api.Send(msg)
+
+	case data == "sb_toggle":
+		if b.preferences == nil {
+			b.sendMessage(chatID, i18n.T(lang, "prefs.unavailable"))
+			return
+		}
+		if err := b.preferences.ToggleSponsorBlock(chatID); err != nil {
+			b.sendMessage(chatID, i18n.T(lang, "prefs.save_failed"))
+			return
+		}
+		prefs := b.preferences.GetPreferences(chatID)
+		text := i18n.T(lang, "menu.sponsorblock_off")
+		if prefs.SponsorBlock {
+			text = i18n.T(lang, "menu.sponsorblock_on")
+		}
+		keyboard := createQualityKeyboard(lang, prefs)
 		msg := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
 		msg.ReplyMarkup = &keyboard
 		b.api.Send(msg)
 
 	case strings.HasPrefix(data, "media_"):
 		if b.preferences == nil {
-			b.sendMessage(chatID, "❌ Система предпочтений недоступна")
+			b.sendMessage(chatID, i18n.T(lang, "prefs.unavailable"))
 			return
 		}
 		mediaType := strings.TrimPrefix(data, "media_")
 		if err := b.preferences.SetMediaType(chatID, mediaType); err != nil {
-			b.sendMessage(chatID, "❌ Ошибка при сохранении настроек")
+			b.sendMessage(chatID, i18n.T(lang, "prefs.save_failed"))
 			return
 		}
 		var text string
 		if mediaType == "audio" {
-			text = "✅ Режим установлен: скачивание аудио (MP3)\n\nТеперь отправь ссылку на видео."
+			prefs := b.preferences.GetPreferences(chatID)
+			text = i18n.T(lang, "menu.media_audio_set", formatAudioPrefs(lang, prefs))
 		} else {
 			prefs := b.preferences.GetPreferences(chatID)
-			text = fmt.Sprintf("✅ Режим установлен: скачивание видео\nКачество: %s", prefs.Quality)
+			text = i18n.T(lang, "menu.media_video_set", prefs.Quality)
 		}
-		keyboard := createMediaTypeKeyboard()
+		keyboard := createMediaTypeKeyboard(lang)
 		msg := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
 		msg.ReplyMarkup = &keyboard
 		b.api.Send(msg)
@@ -346,7 +629,7 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 		// Format: dl_q_<quality>:<jobID>
 		parts := strings.SplitN(data, ":", 2)
 		if len(parts) != 2 {
-			b.sendMessage(chatID, "❌ Ошибка: неверный формат запроса")
+			b.sendMessage(chatID, i18n.T(lang, "callback.bad_request"))
 			return
 		}
 		qualityPart := strings.TrimPrefix(parts[0], "dl_q_")
@@ -354,30 +637,59 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 
 		// Retrieve URL from Redis
 		if b.preferences == nil {
-			b.sendMessage(chatID, "❌ Система недоступна. Попробуйте отправить ссылку снова.")
+			b.sendMessage(chatID, i18n.T(lang, "callback.unavailable"))
 			return
 		}
 
-		url, err := b.preferences.GetPendingURL(jobID)
+		pending, err := b.preferences.GetPendingDownload(jobID)
 		if err != nil {
-			b.sendMessage(chatID, "❌ Ссылка устарела или не найдена. Пожалуйста, отправьте ссылку снова.")
+			b.sendMessage(chatID, i18n.T(lang, "callback.link_expired"))
 			return
 		}
 
+		url := pending.URL
+
 		// Validate URL
 		if !isValidURL(url) {
-			b.sendMessage(chatID, "❌ Неверная ссылка")
+			b.sendMessage(chatID, i18n.T(lang, "callback.bad_link"))
 			return
 		}
 
 		// Determine media type based on quality
-		mediaType := "video"
-		if qualityPart == "audio" {
-			mediaType = "audio"
+		mediaType := mediaTypeForQuality(qualityPart)
+
+		// Chapter splitting is an audio download with an extra step
+		splitChapters := qualityPart == "chapters"
+		if splitChapters {
+			qualityPart = "audio"
 		}
 
-		// Save preferences if available
-		if b.preferences != nil {
+		if limitText, ok := b.allowJobs(chatID, 1, lang); !ok {
+			b.sendMessage(chatID, limitText)
+			return
+		}
+
+		// Create job
+		job := b.newJob(chatID, url, qualityPart, mediaType, lang)
+		job.ClipStart = pending.ClipStart
+		job.ClipEnd = pending.ClipEnd
+		job.ReplyTo = callbackReplyTarget(query)
+		if splitChapters {
+			job.Chapters = true
+			job.Delivery = "" // Chapters always go out as an audio batch
+		}
+
+		// Add to queue
+		if err := b.queue.Enqueue(job); err != nil {
+			b.sendMessage(chatID, i18n.T(lang, "queue.enqueue_failed"))
+			return
+		}
+
+		// Save preferences once the job is accepted. GIF and chapters are one-off
+		// choices here, otherwise auto-downloaded links would follow them too.
+		// Any member may pick a quality in a group, but only admins change its defaults.
+		remember := !isGroup(query.Message.Chat) || b.isGroupAdmin(chatID, query.From.ID)
+		if qualityPart != "gif" && !splitChapters && remember {
 			if qualityPart == "audio" {
 				b.preferences.SetMediaType(chatID, "audio")
 			} else {
@@ -385,44 +697,100 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 			}
 		}
 
-		// Create job
-		job := &queue.Job{
-			ID:        generateJobID(),
-			URL:       url,
-			ChatID:    chatID,
-			Priority:  queue.PriorityLow,
-			Quality:   qualityPart,
-			MediaType: mediaType,
-			CreatedAt: time.Now(),
+		// Delete the keyboard message
+		deleteMsg := tgbotapi.NewDeleteMessage(chatID, query.Message.MessageID)
+		b.api.Send(deleteMsg)
+
+	case strings.HasPrefix(data, "sr_"):
+		// Format: sr_<index>:<searchID>
+		parts := strings.SplitN(data, ":", 2)
+		index, err := strconv.Atoi(strings.TrimPrefix(parts[0], "sr_"))
+		if len(parts) != 2 || err != nil {
+			b.sendMessage(chatID, i18n.T(lang, "callback.bad_request"))
+			return
 		}
+		b.downloadSearchResult(chatID, callbackReplyTarget(query), parts[1], index, lang)
 
-		if b.isDonor(chatID) {
-			job.Priority = queue.PriorityHigh
+	case strings.HasPrefix(data, "hist_page:"):
+		page, err := strconv.Atoi(strings.TrimPrefix(data, "hist_page:"))
+		if err != nil || page < 0 {
+			return
 		}
+		b.showHistoryPage(chatID, query.Message.MessageID, page, lang)
 
-		// Add to queue
-		if err := b.queue.Enqueue(job); err != nil {
-			b.sendMessage(chatID, "❌ Ошибка при добавлении задачи в очередь. Попробуйте позже.")
+	case strings.HasPrefix(data, "hist_send:"):
+		b.resendFromHistory(query, strings.TrimPrefix(data, "hist_send:"), lang)
+
+	case strings.HasPrefix(data, "live_"):
+		// Format: live_<minutes>:<jobID> or live_cancel:<jobID>
+		parts := strings.SplitN(data, ":", 2)
+		if len(parts) != 2 {
+			b.sendMessage(chatID, i18n.T(lang, "callback.bad_request"))
+			return
+		}
+		choice := strings.TrimPrefix(parts[0], "live_")
+
+		// Check the choice first, a rejected one leaves the job pending
+		// so another duration can still be picked
+		var minutes int
+		if choice != "cancel" {
+			maxMinutes := executor.MaxRecordMinutes(b.config, b.donors.Tier(chatID))
+			var err error
+			minutes, err = strconv.Atoi(choice)
+			if err != nil || minutes <= 0 || minutes > maxMinutes {
+				b.sendMessage(chatID, i18n.T(lang, "live.too_long", maxMinutes))
+				return
+			}
+		}
+
+		job, err := b.queue.TakePending(parts[1])
+		if err != nil {
+			b.sendMessage(chatID, i18n.T(lang, "callback.request_expired"))
 			return
 		}
 
-		// Delete the keyboard message
 		deleteMsg := tgbotapi.NewDeleteMessage(chatID, query.Message.MessageID)
+		if choice == "cancel" {
+			b.api.Send(deleteMsg)
+			return
+		}
+
+		job.RecordSeconds = minutes * 60
+		if err := b.queue.Enqueue(job); err != nil {
+			b.sendMessage(chatID, i18n.T(lang, "queue.enqueue_failed"))
+			return
+		}
 		b.api.Send(deleteMsg)
+		b.sendMessage(chatID, i18n.T(lang, "live.recording", minutes))
 	}
 }
 
-func (b *Bot) showStatus(chatID int64) {
+func (b *Bot) showStatus(chat *tgbotapi.Chat, lang string) {
+	chatID := chat.ID
 	status := b.queue.GetStatus()
-	var prefs *UserPreferences
-	if b.preferences != nil {
-		prefs = b.preferences.GetPreferences(chatID)
-	}
-	if prefs == nil {
-		prefs = &UserPreferences{Quality: "best", MediaType: "video"}
-	}
-	text := fmt.Sprintf("📊 Очередь: %d задач\n\n⚙️ Текущие настройки:\nКачество: %s\nТип: %s", status, prefs.Quality, prefs.MediaType)
+	prefs := b.getPreferences(chatID)
+	text := i18n.T(lang, "status", status, prefs.Quality, prefs.MediaType, formatAudioPrefs(lang, prefs), formatSubtitlePrefs(lang, prefs))
 	msg := tgbotapi.NewMessage(chatID, text)
-	msg.ReplyMarkup = createMainKeyboard()
+	msg.ReplyMarkup = b.mainKeyboard(chat, lang)
 	b.api.Send(msg)
 }
+
+// formatAudioPrefs renders audio format and bitrate, e.g. "MP3, 192k"
+func formatAudioPrefs(lang string, prefs *UserPreferences) string {
+	bitrate := prefs.AudioBitrate + "k"
+	if prefs.AudioBitrate == "best" || prefs.AudioFormat == "flac" {
+		bitrate = i18n.T(lang, "prefs.bitrate_best")
+	}
+	return fmt.Sprintf("%s, %s", strings.ToUpper(prefs.AudioFormat), bitrate)
+}
+
+// formatSubtitlePrefs renders subtitle settings, e.g. "файлом, EN"
+func formatSubtitlePrefs(lang string, prefs *UserPreferences) string {
+	switch prefs.SubMode {
+	case "file":
+		return i18n.T(lang, "prefs.subs_file", strings.ToUpper(prefs.SubLang))
+	case "burn":
+		return i18n.T(lang, "prefs.subs_burn", strings.ToUpper(prefs.SubLang))
+	}
+	return i18n.T(lang, "prefs.subs_off")
+}
@@ -0,0 +1,103 @@
+package bot
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"envedour-bot/internal/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// broadcastInterval keeps broadcasts under Telegram's limit of about
+// 30 messages per second, leaving room for regular replies
+const broadcastInterval = 50 * time.Millisecond
+
+// startBroadcast sends the command text, or the message it replies to,
+// to all known users in the background
+func (b *Bot) startBroadcast(msg *tgbotapi.Message, lang string) string {
+	text := strings.TrimSpace(msg.CommandArguments())
+	if text == "" && msg.ReplyToMessage == nil {
+		return i18n.T(lang, "admin.broadcast_usage")
+	}
+
+	users, err := b.users.Known()
+	if err != nil {
+		return i18n.T(lang, "admin.users_failed", err)
+	}
+	if len(users) == 0 {
+		return i18n.T(lang, "admin.no_users")
+	}
+	if !b.broadcasting.CompareAndSwap(false, true) {
+		return i18n.T(lang, "admin.broadcast_busy")
+	}
+
+	var message func(chatID int64) tgbotapi.Chattable
+	if text != "" {
+		message = func(chatID int64) tgbotapi.Chattable {
+			return tgbotapi.NewMessage(chatID, text)
+		}
+	} else {
+		// Copying keeps media and formatting of the original message
+		from, id := msg.Chat.ID, msg.ReplyToMessage.MessageID
+		message = func(chatID int64) tgbotapi.Chattable {
+			return tgbotapi.NewCopyMessage(chatID, from, id)
+		}
+	}
+
+	go func() {
+		defer b.broadcasting.Store(false)
+		b.sendMessage(msg.Chat.ID, b.broadcast(users, message, lang))
+	}()
+	return i18n.T(lang, "admin.broadcast_started", len(users), formatWait(lang, time.Duration(len(users))*broadcastInterval))
+}
+
+// broadcast sends the message to every user and returns a report in the admin's language
+func (b *Bot) broadcast(users []int64, message func(chatID int64) tgbotapi.Chattable, lang string) string {
+	ticker := time.NewTicker(broadcastInterval)
+	defer ticker.Stop()
+
+	sent, gone, failed := 0, 0, 0
+	for _, chatID := range users {
+		<-ticker.C
+		err := b.sendWithRetry(message(chatID))
+		var apiErr *tgbotapi.Error
+		switch {
+		case err == nil:
+			sent++
+		case errors.As(err, &apiErr) && chatGone(apiErr):
+			b.users.Forget(chatID)
+			gone++
+		default:
+			log.Printf("Broadcast to %d failed: %v", chatID, err)
+			failed++
+		}
+	}
+
+	report := i18n.T(lang, "admin.broadcast_done", sent, gone)
+	if failed > 0 {
+		report += i18n.T(lang, "admin.broadcast_errors", failed)
+	}
+	return report
+}
+
+// chatGone reports whether the user blocked the bot or deleted the account.
+// Other bad requests mean the message itself is wrong and are kept as errors.
+func chatGone(err *tgbotapi.Error) bool {
+	return err.Code == http.StatusForbidden ||
+		(err.Code == http.StatusBadRequest && strings.Contains(err.Message, "chat not found"))
+}
+
+// sendWithRetry sends the message, waiting once if Telegram asks to slow down
+func (b *Bot) sendWithRetry(c tgbotapi.Chattable) error {
+	_, err := b.api.Request(c)
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		time.Sleep(time.Duration(apiErr.RetryAfter) * time.Second)
+		_, err = b.api.Request(c)
+	}
+	return err
+}
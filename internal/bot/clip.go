@@ -0,0 +1,142 @@
+package bot
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"envedour-bot/internal/i18n"
+)
+
+// parseClipRange parses the fragment spec users write after a link:
+//
+//	1:20-2:05   from 1:20 to 2:05
+//	1:20-       from 1:20 to the end
+//	-2:05       from the start (or the link's ?t= hint) to 2:05
+//
+// Returns zeros when spec is empty.
+func parseClipRange(spec, rawURL string) (start, end float64, err error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return 0, 0, nil
+	}
+
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected range like 1:20-2:05, got %q", spec)
+	}
+
+	if s := strings.TrimSpace(parts[0]); s != "" {
+		if start, err = parseTimestamp(s); err != nil {
+			return 0, 0, err
+		}
+	} else {
+		start = timeHint(rawURL)
+	}
+
+	if e := strings.TrimSpace(parts[1]); e != "" {
+		if end, err = parseTimestamp(e); err != nil {
+			return 0, 0, err
+		}
+		if end <= start {
+			return 0, 0, fmt.Errorf("end %s is not after start", e)
+		}
+	}
+
+	return start, end, nil
+}
+
+// looksLikeRange reports whether text after a link is meant as a fragment
+// spec: digits with ":", ".", "-" and spaces only. Anything else is a
+// comment and the whole link is downloaded.
+func looksLikeRange(spec string) bool {
+	digits := false
+	for _, r := range spec {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = true
+		case r == ':' || r == '.' || r == '-' || unicode.IsSpace(r):
+		default:
+			return false
+		}
+	}
+	return digits
+}
+
+// parseTimestamp accepts "80", "80.5", "1:20" and "1:02:03"
+func parseTimestamp(s string) (float64, error) {
+	fields := strings.Split(s, ":")
+	if len(fields) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	var total float64
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		// Minutes and seconds after the first field must be below 60
+		if i > 0 && v >= 60 {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		total = total*60 + v
+	}
+	return total, nil
+}
+
+// timeHint returns the start offset from YouTube-style ?t= parameters
+// ("t=80", "t=80s", "t=1m20s", "t=1h2m3s"), or 0 if there is none
+func timeHint(rawURL string) float64 {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
+	t := u.Query().Get("t")
+	if t == "" {
+		t = u.Query().Get("start")
+	}
+	if t == "" {
+		return 0
+	}
+
+	if v, err := strconv.ParseFloat(strings.TrimSuffix(t, "s"), 64); err == nil {
+		return v
+	}
+
+	var total, num float64
+	for _, r := range t {
+		switch {
+		case r >= '0' && r <= '9':
+			num = num*10 + float64(r-'0')
+		case r == 'h':
+			total, num = total+num*3600, 0
+		case r == 'm':
+			total, num = total+num*60, 0
+		case r == 's':
+			total, num = total+num, 0
+		default:
+			return 0
+		}
+	}
+	return total + num
+}
+
+// formatTimestamp renders seconds as m:ss or h:mm:ss
+func formatTimestamp(seconds float64) string {
+	s := int(seconds)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s%3600/60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// formatClipRange renders a fragment for user-facing messages
+func formatClipRange(lang string, start, end float64) string {
+	if end == 0 {
+		return i18n.T(lang, "clip.to_end", formatTimestamp(start))
+	}
+	return formatTimestamp(start) + " – " + formatTimestamp(end)
+}
@@ -0,0 +1,158 @@
+package bot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// fakeRedis speaks just enough of the Redis protocol for the stores used
+// in tests: GET, SET (expiry is ignored), SETNX, DEL, SADD, SREM and SISMEMBER
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+	sets map[string]map[string]bool
+}
+
+// newFakeRedis starts a fake Redis server and returns a client for it
+func newFakeRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeRedis{data: make(map[string]string), sets: make(map[string]map[string]bool)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+
+	client := redis.NewClient(&redis.Options{Addr: ln.Addr().String()})
+	t.Cleanup(func() {
+		client.Close()
+		ln.Close()
+	})
+	return client
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, r.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand reads a command sent as an array of bulk strings
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2) // Value and \r\n
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (r *fakeRedis) exec(args []string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "GET":
+		if value, ok := r.data[args[1]]; ok {
+			return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+		}
+		return "$-1\r\n"
+	case "SET":
+		nx := false
+		for _, opt := range args[3:] {
+			nx = nx || strings.EqualFold(opt, "NX")
+		}
+		if _, exists := r.data[args[1]]; nx && exists {
+			return "$-1\r\n"
+		}
+		r.data[args[1]] = args[2]
+		return "+OK\r\n"
+	case "SETNX":
+		if _, exists := r.data[args[1]]; exists {
+			return ":0\r\n"
+		}
+		r.data[args[1]] = args[2]
+		return ":1\r\n"
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := r.data[key]; ok {
+				delete(r.data, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	case "SADD":
+		set := r.sets[args[1]]
+		if set == nil {
+			set = make(map[string]bool)
+			r.sets[args[1]] = set
+		}
+		added := 0
+		for _, member := range args[2:] {
+			if !set[member] {
+				set[member] = true
+				added++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", added)
+	case "SREM":
+		removed := 0
+		for _, member := range args[2:] {
+			if r.sets[args[1]][member] {
+				delete(r.sets[args[1]], member)
+				removed++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", removed)
+	case "SISMEMBER":
+		if r.sets[args[1]][args[2]] {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+}
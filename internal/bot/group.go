@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// groupPublicCallbacks are buttons any group member may press. Everything
+// else changes the group's settings and is reserved for admins.
+var groupPublicCallbacks = []string{"dl_q_", "sr_", "live_", "cmd_status", "sub_check", "hist_"}
+
+func isGroup(chat *tgbotapi.Chat) bool {
+	return chat != nil && (chat.IsGroup() || chat.IsSuperGroup())
+}
+
+// handleGroupMessage reacts only to messages meant for the bot, so links
+// shared between members aren't downloaded unless the admins asked for it
+func (b *Bot) handleGroupMessage(msg *tgbotapi.Message, lang string) {
+	switch {
+	case msg.IsCommand():
+		// "/start@other_bot" is not for us
+		if at := strings.Index(msg.CommandWithAt(), "@"); at >= 0 &&
+			!strings.EqualFold(msg.CommandWithAt()[at+1:], b.api.Self.UserName) {
+			return
+		}
+		b.handleCommand(msg, lang)
+	case b.mentionsBot(msg) || b.repliesToBot(msg):
+		b.handleURL(msg, lang)
+	case b.getPreferences(msg.Chat.ID).GroupAutoDownload && len(messageURLs(msg)) > 0:
+		b.handleURL(msg, lang)
+	}
+}
+
+// mentionsBot reports whether the message mentions the bot by @username
+func (b *Bot) mentionsBot(msg *tgbotapi.Message) bool {
+	mention := "@" + b.api.Self.UserName
+	check := func(text string, entities []tgbotapi.MessageEntity) bool {
+		for _, entity := range entities {
+			if entity.Type == "mention" && strings.EqualFold(entityText(text, entity), mention) {
+				return true
+			}
+		}
+		return false
+	}
+	return check(msg.Text, msg.Entities) || check(msg.Caption, msg.CaptionEntities)
+}
+
+// repliesToBot reports whether the message is a reply to one of the bot's messages
+func (b *Bot) repliesToBot(msg *tgbotapi.Message) bool {
+	reply := msg.ReplyToMessage
+	return reply != nil && reply.From != nil && reply.From.ID == b.api.Self.ID
+}
+
+// stripMention removes the bot's @username so it isn't taken for a link or query
+func (b *Bot) stripMention(text string) string {
+	mention := "@" + b.api.Self.UserName
+	if i := strings.Index(strings.ToLower(text), strings.ToLower(mention)); i >= 0 {
+		text = text[:i] + text[i+len(mention):]
+	}
+	return strings.TrimSpace(text)
+}
+
+// replyTarget returns the message answers should reply to. Only groups
+// need it, in private chats it would just quote every link back.
+func replyTarget(msg *tgbotapi.Message) int {
+	if isGroup(msg.Chat) {
+		return msg.MessageID
+	}
+	return 0
+}
+
+// callbackReplyTarget returns the original message a keyboard was shown for
+func callbackReplyTarget(query *tgbotapi.CallbackQuery) int {
+	if query.Message.ReplyToMessage != nil && isGroup(query.Message.Chat) {
+		return query.Message.ReplyToMessage.MessageID
+	}
+	return 0
+}
+
+// reply sends text to the message's chat, replying to it in groups
+func (b *Bot) reply(msg *tgbotapi.Message, text string) {
+	message := tgbotapi.NewMessage(msg.Chat.ID, text)
+	message.ReplyToMessageID = replyTarget(msg)
+	b.api.Send(message)
+}
+
+// canChangeSettings reports whether the user pressing a button may do so.
+// In groups settings are shared, so only admins can change them.
+func (b *Bot) canChangeSettings(query *tgbotapi.CallbackQuery) bool {
+	if !isGroup(query.Message.Chat) {
+		return true
+	}
+	for _, prefix := range groupPublicCallbacks {
+		if strings.HasPrefix(query.Data, prefix) {
+			return true
+		}
+	}
+	return b.isGroupAdmin(query.Message.Chat.ID, query.From.ID)
+}
+
+func (b *Bot) isGroupAdmin(chatID, userID int64) bool {
+	member, err := b.api.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: userID},
+	})
+	return err == nil && (member.IsAdministrator() || member.IsCreator())
+}
+
+// mainKeyboard returns the main menu, with group-only settings in groups
+func (b *Bot) mainKeyboard(chat *tgbotapi.Chat, lang string) tgbotapi.InlineKeyboardMarkup {
+	keyboard := createMainKeyboard(lang)
+	if isGroup(chat) {
+		prefs := b.getPreferences(chat.ID)
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, createGroupRow(lang, prefs))
+	}
+	return keyboard
+}
@@ -0,0 +1,169 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"envedour-bot/internal/history"
+	"envedour-bot/internal/i18n"
+	"envedour-bot/internal/queue"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// historyPageSize is how many downloads one /history page shows
+const historyPageSize = 5
+
+// showHistory sends the first page of the chat's recent downloads
+func (b *Bot) showHistory(chatID int64, lang string) {
+	text, keyboard := b.historyPage(chatID, 0, lang)
+	msg := tgbotapi.NewMessage(chatID, text)
+	if keyboard != nil {
+		msg.ReplyMarkup = *keyboard
+	}
+	b.api.Send(msg)
+}
+
+// showHistoryPage replaces the history message with another page
+func (b *Bot) showHistoryPage(chatID int64, messageID, page int, lang string) {
+	text, keyboard := b.historyPage(chatID, page, lang)
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ReplyMarkup = keyboard
+	b.api.Send(edit)
+}
+
+// historyPage renders a page of the history with re-send buttons
+func (b *Bot) historyPage(chatID int64, page int, lang string) (string, *tgbotapi.InlineKeyboardMarkup) {
+	entries, total, err := b.history.List(chatID, page*historyPageSize, historyPageSize)
+	if err != nil {
+		return i18n.T(lang, "history.load_failed"), nil
+	}
+	if total == 0 {
+		return i18n.T(lang, "history.empty"), nil
+	}
+	pages := (total + historyPageSize - 1) / historyPageSize
+	if page >= pages {
+		// The page is gone, e.g. the history was trimmed meanwhile.
+		// Show the last one instead, once.
+		page = pages - 1
+		if entries, total, err = b.history.List(chatID, page*historyPageSize, historyPageSize); err != nil {
+			return i18n.T(lang, "history.load_failed"), nil
+		}
+		if len(entries) == 0 {
+			return i18n.T(lang, "history.empty"), nil
+		}
+		pages = (total + historyPageSize - 1) / historyPageSize
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "history.header", page+1, pages))
+	for i, entry := range entries {
+		sb.WriteString(fmt.Sprintf("\n%d. %s\n%s · %s\n",
+			page*historyPageSize+i+1, historyTitle(entry), formatHistoryQuality(lang, entry.Job), entry.Date.Format("02.01.2006 15:04")))
+	}
+	sb.WriteString(i18n.T(lang, "history.footer"))
+
+	keyboard := createHistoryKeyboard(lang, entries, page, pages)
+	return sb.String(), &keyboard
+}
+
+// historyTitle returns the entry's title, shortened for the list
+func historyTitle(entry *history.Entry) string {
+	title := entry.Title
+	if title == "" {
+		title = entry.Job.URL
+	}
+	if utf8.RuneCountInString(title) > 60 {
+		title = string([]rune(title)[:59]) + "…"
+	}
+	return title
+}
+
+// formatHistoryQuality renders what was downloaded, e.g. "🎬 1080p" or "🎵 MP3"
+func formatHistoryQuality(lang string, job *queue.Job) string {
+	var text string
+	switch {
+	case job.MediaType == "animation":
+		text = "🎞 GIF"
+	case job.Chapters:
+		text = i18n.T(lang, "history.chapters", strings.ToUpper(job.AudioFormat))
+	case job.MediaType == "audio":
+		text = "🎵 " + strings.ToUpper(job.AudioFormat)
+	default:
+		text = "🎬 " + job.Quality
+	}
+	if job.IsClipped() {
+		text += i18n.T(lang, "history.fragment", formatClipRange(lang, job.ClipStart, job.ClipEnd))
+	}
+	return text
+}
+
+// resendFromHistory sends a file from the history again. Files Telegram
+// still has are sent by file ID, others are downloaded again.
+func (b *Bot) resendFromHistory(query *tgbotapi.CallbackQuery, jobID, lang string) {
+	chatID := query.Message.Chat.ID
+	entry, err := b.history.Get(chatID, jobID)
+	if err != nil || entry == nil {
+		b.sendMessage(chatID, i18n.T(lang, "history.not_found"))
+		return
+	}
+
+	if entry.File != nil {
+		err := b.sendCachedFile(chatID, entry.File)
+		if err == nil {
+			return
+		}
+		log.Printf("Failed to resend %s from history: %v", entry.File.FileID, err)
+	}
+
+	if b.needsSubscription(query.From.ID) {
+		b.sendMessage(chatID, i18n.T(lang, "sub.required"))
+		return
+	}
+	if limitText, ok := b.allowJobs(chatID, 1, lang); !ok {
+		b.sendMessage(chatID, limitText)
+		return
+	}
+
+	job := *entry.Job
+	job.ID = generateJobID()
+	job.ReplyTo = 0
+	job.CreatedAt = time.Now()
+	job.Lang = lang
+	job.Priority = queue.PriorityLow
+	if b.isDonor(chatID) {
+		job.Priority = queue.PriorityHigh
+	}
+	if err := b.queue.Enqueue(&job); err != nil {
+		b.sendMessage(chatID, i18n.T(lang, "queue.enqueue_failed"))
+		return
+	}
+	b.sendMessage(chatID, i18n.T(lang, "history.redownloading", historyTitle(entry)))
+}
+
+// sendCachedFile sends a file already uploaded to Telegram
+func (b *Bot) sendCachedFile(chatID int64, file *queue.CachedFile) error {
+	id := tgbotapi.FileID(file.FileID)
+	var msg tgbotapi.Chattable
+	switch file.Type {
+	case "video":
+		video := tgbotapi.NewVideo(chatID, id)
+		video.SupportsStreaming = true
+		msg = video
+	case "audio":
+		msg = tgbotapi.NewAudio(chatID, id)
+	case "voice":
+		msg = tgbotapi.NewVoice(chatID, id)
+	case "video_note":
+		msg = tgbotapi.NewVideoNote(chatID, 0, id)
+	case "animation":
+		msg = tgbotapi.NewAnimation(chatID, id)
+	default:
+		return fmt.Errorf("unknown file type %q", file.Type)
+	}
+	_, err := b.api.Send(msg)
+	return err
+}
@@ -0,0 +1,203 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"envedour-bot/internal/executor"
+	"envedour-bot/internal/extractor"
+	"envedour-bot/internal/i18n"
+	"envedour-bot/internal/queue"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// inlineCacheTime is how long Telegram may reuse answers to the same inline query
+const inlineCacheTime = 300
+
+// Inline searches start on every keystroke, these keep them in check
+const (
+	inlineMinQueryLen       = 3               // Shorter queries aren't searched
+	inlineSearchesPerMinute = 12              // Per user, on top of Telegram's own debounce
+	inlineSearchCacheTTL    = 5 * time.Minute // Results are reused for the same query
+	maxInlineSearches       = 4               // yt-dlp searches running at once
+)
+
+// handleInlineQuery answers "@bot <link or query>" with files that were
+// already uploaded, or with placeholders that start a download when chosen
+func (b *Bot) handleInlineQuery(query *tgbotapi.InlineQuery, lang string) {
+	text := strings.TrimSpace(query.Query)
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		CacheTime:     inlineCacheTime,
+		IsPersonal:    true, // Results depend on the user's preferences
+	}
+
+	var results []executor.SearchResult
+	switch {
+	case b.preferences == nil:
+		answer.SwitchPMText = i18n.T(lang, "inline.unavailable")
+		answer.SwitchPMParameter = "inline"
+	case b.needsSubscription(query.From.ID):
+		answer.SwitchPMText = i18n.T(lang, "inline.subscribe")
+		answer.SwitchPMParameter = "subscribe"
+		answer.CacheTime = 0
+	case isValidURL(text):
+		url := extractor.Canonicalize(context.Background(), text)
+		results = []executor.SearchResult{{Title: i18n.T(lang, "inline.download"), URL: url}}
+	case text != "":
+		search, soundcloud := parseSearchQuery(text)
+		if utf8.RuneCountInString(search) < inlineMinQueryLen {
+			answer.CacheTime = 0
+			break
+		}
+		// A search takes seconds, don't hold the update slot meanwhile
+		go b.answerInlineSearch(query, answer, search, soundcloud, lang)
+		return
+	default:
+		answer.SwitchPMText = i18n.T(lang, "inline.hint")
+		answer.SwitchPMParameter = "inline"
+	}
+
+	b.sendInlineAnswer(answer, query.From.ID, results, lang)
+}
+
+// answerInlineSearch answers with search results, cached ones if the same
+// query was searched recently. Users over the search limit and queries
+// arriving while all search slots are busy get an empty answer that
+// Telegram doesn't cache, so the next keystroke tries again.
+func (b *Bot) answerInlineSearch(query *tgbotapi.InlineQuery, answer tgbotapi.InlineConfig, search string, soundcloud bool, lang string) {
+	results, cached := b.preferences.GetCachedSearch(search, soundcloud)
+	if !cached {
+		if b.limiter != nil && !b.limiter.AllowSearch(query.From.ID, inlineSearchesPerMinute) {
+			answer.CacheTime = 0
+			b.sendInlineAnswer(answer, query.From.ID, nil, lang)
+			return
+		}
+		select {
+		case b.inlineSearches <- struct{}{}:
+		default:
+			answer.CacheTime = 0
+			b.sendInlineAnswer(answer, query.From.ID, nil, lang)
+			return
+		}
+		found, err := b.executor.Search(context.Background(), search, soundcloud)
+		<-b.inlineSearches
+		if err != nil {
+			log.Printf("Inline search for %q failed: %v", search, err)
+			answer.CacheTime = 0
+		} else {
+			b.preferences.SaveCachedSearch(search, soundcloud, found, inlineSearchCacheTTL)
+		}
+		results = found
+	}
+	b.sendInlineAnswer(answer, query.From.ID, results, lang)
+}
+
+// sendInlineAnswer adds the results to the answer and sends it
+func (b *Bot) sendInlineAnswer(answer tgbotapi.InlineConfig, userID int64, results []executor.SearchResult, lang string) {
+	if len(results) > 0 {
+		searchID := generateJobID()
+		if err := b.preferences.SaveSearchResults(searchID, results); err != nil {
+			log.Printf("Failed to save inline results: %v", err)
+			results = nil
+		}
+		for i, result := range results {
+			id := fmt.Sprintf("%d:%s", i, searchID)
+			answer.Results = append(answer.Results, b.inlineResult(id, userID, result, lang))
+		}
+	}
+
+	if _, err := b.api.Request(answer); err != nil {
+		log.Printf("Failed to answer inline query: %v", err)
+	}
+}
+
+// inlineResult returns the cached file for the result if there is one,
+// otherwise a placeholder message the file is put into after download
+func (b *Bot) inlineResult(id string, userID int64, result executor.SearchResult, lang string) interface{} {
+	if key := b.inlineJob(userID, result.URL, lang).CacheKey(); key != "" {
+		if file, err := b.queue.GetFile(key); err == nil {
+			switch file.Type {
+			case "video":
+				return tgbotapi.NewInlineQueryResultCachedVideo(id, file.FileID, result.Title)
+			case "audio":
+				return tgbotapi.NewInlineQueryResultCachedAudio(id, file.FileID)
+			case "animation":
+				return tgbotapi.NewInlineQueryResultCachedMPEG4GIF(id, file.FileID)
+			}
+		}
+	}
+
+	article := tgbotapi.NewInlineQueryResultArticle(id, formatSearchResult(result), i18n.T(lang, "inline.loading", result.Title))
+	article.Description = result.URL
+	// Only messages with a keyboard get an inline message ID to edit later
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonURL(i18n.T(lang, "inline.open"), result.URL),
+	))
+	article.ReplyMarkup = &keyboard
+	return article
+}
+
+// handleChosenInlineResult starts the download for a placeholder the user sent.
+// Requires inline feedback to be enabled in @BotFather.
+func (b *Bot) handleChosenInlineResult(chosen *tgbotapi.ChosenInlineResult, lang string) {
+	// Cached files are sent by Telegram directly and have nothing to edit
+	if chosen.InlineMessageID == "" || b.preferences == nil {
+		return
+	}
+
+	parts := strings.SplitN(chosen.ResultID, ":", 2)
+	index, err := strconv.Atoi(parts[0])
+	if len(parts) != 2 || err != nil {
+		return
+	}
+	results, err := b.preferences.GetSearchResults(parts[1])
+	if err != nil || index < 0 || index >= len(results) {
+		b.editInlineText(chosen.InlineMessageID, i18n.T(lang, "inline.expired"))
+		return
+	}
+
+	// Limits count against the user, the file may go to INLINE_CHAT_ID
+	if limitText, ok := b.allowRequest(chosen.From.ID, lang); !ok {
+		b.editInlineText(chosen.InlineMessageID, limitText)
+		return
+	}
+	if limitText, ok := b.allowJobs(chosen.From.ID, 1, lang); !ok {
+		b.editInlineText(chosen.InlineMessageID, limitText)
+		return
+	}
+
+	job := b.inlineJob(chosen.From.ID, results[index].URL, lang)
+	job.InlineMessage = chosen.InlineMessageID
+	if b.config.InlineChatID != 0 {
+		job.ChatID = b.config.InlineChatID
+		job.UserID = chosen.From.ID
+	}
+	if err := b.queue.Enqueue(job); err != nil {
+		b.editInlineText(chosen.InlineMessageID, i18n.T(lang, "queue.enqueue_failed"))
+	}
+}
+
+// inlineJob creates a job with the user's defaults. Inline messages can only
+// hold regular files, so voice, round video and subtitles are turned off.
+func (b *Bot) inlineJob(userID int64, url, lang string) *queue.Job {
+	prefs := b.getPreferences(userID)
+	job := b.newJob(userID, url, prefs.Quality, prefs.MediaType, lang)
+	job.Delivery = ""
+	job.SubMode = ""
+	return job
+}
+
+func (b *Bot) editInlineText(inlineMessageID, text string) {
+	edit := tgbotapi.EditMessageTextConfig{
+		BaseEdit: tgbotapi.BaseEdit{InlineMessageID: inlineMessageID},
+		Text:     text,
+	}
+	b.api.Request(edit)
+}
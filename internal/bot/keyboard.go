@@ -2,28 +2,93 @@ package bot
 
 import (
 	"fmt"
+	"strings"
+
+	"envedour-bot/internal/executor"
+	"envedour-bot/internal/history"
+	"envedour-bot/internal/i18n"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// onOff picks the label for a toggle button
+func onOff(lang, key string, on bool) string {
+	if on {
+		return i18n.T(lang, key+"_on")
+	}
+	return i18n.T(lang, key+"_off")
+}
+
+// backRow returns to the main menu
+func backRow(lang string) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.back"), "menu_main"),
+	)
+}
+
 // createMainKeyboard creates the main inline keyboard
-func createMainKeyboard() tgbotapi.InlineKeyboardMarkup {
+func createMainKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("⚙️ Качество", "menu_quality"),
-		tgbotapi.NewInlineKeyboardButtonData("🎵 Аудио/Видео", "menu_media"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.quality"), "menu_quality"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.media"), "menu_media"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.audio"), "menu_audio"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.delivery"), "menu_delivery"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 Статус", "cmd_status"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.subs"), "menu_subs"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.status"), "cmd_status"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.language"), "menu_lang"),
 		),
 	)
 }
 
+// subtitleLanguages lists languages offered in the subtitles menu
+var subtitleLanguages = []string{"ru", "en", "uk", "de", "es", "fr"}
+
+// createSubtitlesKeyboard creates keyboard for subtitle mode and language selection
+func createSubtitlesKeyboard(lang string, prefs *UserPreferences) tgbotapi.InlineKeyboardMarkup {
+	var langRow []tgbotapi.InlineKeyboardButton
+	for _, sub := range subtitleLanguages {
+		langRow = append(langRow, tgbotapi.NewInlineKeyboardButtonData(strings.ToUpper(sub), "sub_lang_"+sub))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.subs_off"), "sub_mode_off"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.subs_file"), "sub_mode_file"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.subs_burn"), "sub_mode_burn"),
+		),
+		langRow,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(onOff(lang, "kb.subs_auto", prefs.SubAuto), "sub_auto"),
+		),
+		backRow(lang),
+	)
+}
+
+// createDeliveryKeyboard creates keyboard for delivery mode selection
+func createDeliveryKeyboard(lang string, prefs *UserPreferences) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(onOff(lang, "kb.voice", prefs.VoiceAudio), "dlv_voice"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(onOff(lang, "kb.round", prefs.RoundVideo), "dlv_round"),
+		),
+		backRow(lang),
+	)
+}
+
 // createQualityKeyboard creates keyboard for quality selection
-func createQualityKeyboard() tgbotapi.InlineKeyboardMarkup {
+func createQualityKeyboard(lang string, prefs *UserPreferences) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🏆 Лучшее", "quality_best"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.best"), "quality_best"),
 			tgbotapi.NewInlineKeyboardButtonData("1080p", "quality_1080p"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
@@ -32,33 +97,62 @@ func createQualityKeyboard() tgbotapi.InlineKeyboardMarkup {
 		),
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("360p", "quality_360p"),
-			tgbotapi.NewInlineKeyboardButtonData("🎵 Аудио", "quality_audio"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.audio_only"), "quality_audio"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "menu_main"),
+			tgbotapi.NewInlineKeyboardButtonData(onOff(lang, "kb.sponsorblock", prefs.SponsorBlock), "sb_toggle"),
 		),
+		backRow(lang),
 	)
 }
 
 // createMediaTypeKeyboard creates keyboard for media type selection
-func createMediaTypeKeyboard() tgbotapi.InlineKeyboardMarkup {
+func createMediaTypeKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🎬 Видео", "media_video"),
-			tgbotapi.NewInlineKeyboardButtonData("🎵 Аудио (MP3)", "media_audio"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.video_only"), "media_video"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.audio_only"), "media_audio"),
 		),
+		backRow(lang),
+	)
+}
+
+// createAudioFormatKeyboard creates keyboard for audio format, bitrate and tags selection
+func createAudioFormatKeyboard(lang string, prefs *UserPreferences) tgbotapi.InlineKeyboardMarkup {
+	var formatRow, bitrateRow1, bitrateRow2 []tgbotapi.InlineKeyboardButton
+	for _, format := range executor.AudioFormats {
+		formatRow = append(formatRow, tgbotapi.NewInlineKeyboardButtonData(strings.ToUpper(format), "afmt_"+format))
+	}
+	for i, bitrate := range executor.AudioBitrates {
+		label := bitrate + "k"
+		if bitrate == "best" {
+			label = i18n.T(lang, "kb.bitrate_best")
+		}
+		button := tgbotapi.NewInlineKeyboardButtonData(label, "abr_"+bitrate)
+		if i < 3 {
+			bitrateRow1 = append(bitrateRow1, button)
+		} else {
+			bitrateRow2 = append(bitrateRow2, button)
+		}
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		formatRow,
+		bitrateRow1,
+		bitrateRow2,
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "menu_main"),
+			tgbotapi.NewInlineKeyboardButtonData(onOff(lang, "kb.tags", prefs.AudioTags), "atags_toggle"),
 		),
+		backRow(lang),
 	)
 }
 
 // createDownloadQualityKeyboard creates keyboard for quality selection when downloading
 // Uses a job ID instead of full URL to avoid Telegram's 64-byte callback data limit
-func createDownloadQualityKeyboard(jobID string) tgbotapi.InlineKeyboardMarkup {
+func createDownloadQualityKeyboard(lang, jobID string) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🏆 Лучшее", fmt.Sprintf("dl_q_best:%s", jobID)),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.best"), fmt.Sprintf("dl_q_best:%s", jobID)),
 			tgbotapi.NewInlineKeyboardButtonData("1080p", fmt.Sprintf("dl_q_1080p:%s", jobID)),
 		),
 		tgbotapi.NewInlineKeyboardRow(
@@ -67,7 +161,78 @@ func createDownloadQualityKeyboard(jobID string) tgbotapi.InlineKeyboardMarkup {
 		),
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("360p", fmt.Sprintf("dl_q_360p:%s", jobID)),
-			tgbotapi.NewInlineKeyboardButtonData("🎵 Аудио", fmt.Sprintf("dl_q_audio:%s", jobID)),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.audio_only"), fmt.Sprintf("dl_q_audio:%s", jobID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🎞 GIF", fmt.Sprintf("dl_q_gif:%s", jobID)),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.chapters"), fmt.Sprintf("dl_q_chapters:%s", jobID)),
+		),
+	)
+}
+
+// createSearchResultsKeyboard lists search results, one per row
+func createSearchResultsKeyboard(searchID string, results []executor.SearchResult) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, result := range results {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(formatSearchResult(result), fmt.Sprintf("sr_%d:%s", i, searchID)),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// createGroupRow creates the group-only settings row of the main menu
+func createGroupRow(lang string, prefs *UserPreferences) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(onOff(lang, "kb.group_auto", prefs.GroupAutoDownload), "grp_auto_toggle"),
+	)
+}
+
+// createLanguageKeyboard offers every supported language and the automatic choice
+func createLanguageKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, l := range i18n.Languages {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(l, "lang.name"), "lang_"+l))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(
+		row,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "lang.auto"), "lang_auto"),
+		),
+		backRow(lang),
+	)
+}
+
+// createSubscribeKeyboard links to REQUIRED_CHANNEL and re-checks the subscription
+func createSubscribeKeyboard(lang, link string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL(i18n.T(lang, "kb.subscribe"), link),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.check_again"), "sub_check"),
 		),
 	)
 }
+
+// createHistoryKeyboard has a re-send button per entry and page navigation
+func createHistoryKeyboard(lang string, entries []*history.Entry, page, pages int) tgbotapi.InlineKeyboardMarkup {
+	var resend []tgbotapi.InlineKeyboardButton
+	for i, entry := range entries {
+		label := fmt.Sprintf("🔁 %d", page*historyPageSize+i+1)
+		resend = append(resend, tgbotapi.NewInlineKeyboardButtonData(label, "hist_send:"+entry.Job.ID))
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{resend}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.newer"), fmt.Sprintf("hist_page:%d", page-1)))
+	}
+	if page < pages-1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "kb.older"), fmt.Sprintf("hist_page:%d", page+1)))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
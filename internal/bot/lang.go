@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"envedour-bot/internal/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// updateLang picks the language to answer the update in: the one chosen
+// with /lang for the chat, otherwise the sender's Telegram language,
+// otherwise DEFAULT_LANGUAGE
+func (b *Bot) updateLang(update tgbotapi.Update) string {
+	from := update.SentFrom()
+	if chat := updateChat(update); chat != nil {
+		if lang := b.getPreferences(chat.ID).Language; i18n.Supported(lang) {
+			return lang
+		}
+	} else if from != nil {
+		// Inline queries and payments follow the user's private chat
+		if lang := b.getPreferences(from.ID).Language; i18n.Supported(lang) {
+			return lang
+		}
+	}
+	if from != nil {
+		return i18n.Match(from.LanguageCode, b.config.DefaultLanguage)
+	}
+	return b.config.DefaultLanguage
+}
+
+// chatLang returns the language for messages the chat didn't ask for,
+// e.g. notifications from admins
+func (b *Bot) chatLang(chatID int64) string {
+	if lang := b.getPreferences(chatID).Language; i18n.Supported(lang) {
+		return lang
+	}
+	return b.config.DefaultLanguage
+}
+
+// showLanguages sends the language menu for /lang
+func (b *Bot) showLanguages(chatID int64, lang string) {
+	text, keyboard := b.languageMenu(chatID, lang)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = keyboard
+	b.api.Send(msg)
+}
+
+// languageMenu renders the chat's language setting with the language keyboard
+func (b *Bot) languageMenu(chatID int64, lang string) (string, tgbotapi.InlineKeyboardMarkup) {
+	current := i18n.T(lang, "lang.auto")
+	if chosen := b.getPreferences(chatID).Language; i18n.Supported(chosen) {
+		current = i18n.T(chosen, "lang.name")
+	}
+	return i18n.T(lang, "lang.choose", current), createLanguageKeyboard(lang)
+}
+
+// setLanguage handles the language buttons. "auto" goes back to the
+// user's Telegram language.
+func (b *Bot) setLanguage(query *tgbotapi.CallbackQuery, choice, lang string) {
+	chatID := query.Message.Chat.ID
+	if b.preferences == nil {
+		b.sendMessage(chatID, i18n.T(lang, "prefs.unavailable"))
+		return
+	}
+
+	chosen := ""
+	if choice != "auto" {
+		if !i18n.Supported(choice) {
+			return
+		}
+		chosen = choice
+	}
+	if err := b.preferences.SetLanguage(chatID, chosen); err != nil {
+		b.sendMessage(chatID, i18n.T(lang, "prefs.save_failed"))
+		return
+	}
+
+	// Answer in the new language right away
+	lang = chosen
+	if lang == "" {
+		lang = i18n.Match(query.From.LanguageCode, b.config.DefaultLanguage)
+	}
+	name := i18n.T(lang, "lang.auto")
+	if chosen != "" {
+		name = i18n.T(chosen, "lang.name")
+	}
+	keyboard := b.mainKeyboard(query.Message.Chat, lang)
+	msg := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, i18n.T(lang, "lang.set", name))
+	msg.ReplyMarkup = &keyboard
+	b.api.Send(msg)
+}
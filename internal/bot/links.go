@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"unicode/utf16"
+
+	"envedour-bot/internal/extractor"
+	"envedour-bot/internal/i18n"
+	"envedour-bot/internal/queue"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// extractURLs returns the links of the message, or of the message it
+// replies to if the message itself has none
+func extractURLs(msg *tgbotapi.Message) []string {
+	urls := messageURLs(msg)
+	if len(urls) == 0 && msg.ReplyToMessage != nil {
+		urls = messageURLs(msg.ReplyToMessage)
+	}
+	return urls
+}
+
+// messageURLs returns every link in the message text or media caption in
+// order of appearance, without duplicates
+func messageURLs(msg *tgbotapi.Message) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(raw string) {
+		url := normalizeLink(raw)
+		if url == "" || seen[url] {
+			return
+		}
+		seen[url] = true
+		urls = append(urls, url)
+	}
+
+	collectURLs(msg.Text, msg.Entities, add)
+	collectURLs(msg.Caption, msg.CaptionEntities, add)
+	return urls
+}
+
+// collectURLs passes every link in the text to add
+func collectURLs(text string, entities []tgbotapi.MessageEntity, add func(string)) {
+	for _, entity := range entities {
+		switch entity.Type {
+		case "url":
+			add(entityText(text, entity))
+		case "text_link":
+			add(entity.URL)
+		}
+	}
+
+	// Messages without entities (e.g. sent through the API) are scanned word by word
+	if len(entities) == 0 {
+		for _, field := range strings.Fields(text) {
+			if isValidURL(field) {
+				add(field)
+			}
+		}
+	}
+}
+
+// entityText cuts the entity out of the text. Telegram counts offsets in UTF-16 code units.
+func entityText(text string, entity tgbotapi.MessageEntity) string {
+	units := utf16.Encode([]rune(text))
+	end := entity.Offset + entity.Length
+	if entity.Offset < 0 || end > len(units) {
+		return ""
+	}
+	return string(utf16.Decode(units[entity.Offset:end]))
+}
+
+// normalizeLink adds the scheme Telegram allows users to omit ("youtu.be/x").
+// Returns "" if the text isn't a link.
+func normalizeLink(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	if !isValidURL(raw) {
+		return ""
+	}
+	return raw
+}
+
+// defaultJob creates a job for a link with no questions asked: auto-download
+// platforms and direct files in best quality, everything else with the
+// user's default quality and media type
+func (b *Bot) defaultJob(chatID int64, url, lang string) *queue.Job {
+	prefs := b.getPreferences(chatID)
+	quality, mediaType := prefs.Quality, prefs.MediaType
+
+	ex := extractor.ForURL(url)
+	directType := extractor.DirectMediaType(url)
+	if ex.AutoDownload() || directType != "" {
+		quality, mediaType = "best", ex.DefaultMediaType()
+		if directType != "" {
+			mediaType = directType
+		}
+	}
+	return b.newJob(chatID, url, quality, mediaType, lang)
+}
+
+// canonicalURLs canonicalizes the links and drops the ones that turn out to be the same
+func canonicalURLs(urls []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, url := range urls {
+		url = extractor.Canonicalize(context.Background(), url)
+		if !seen[url] {
+			seen[url] = true
+			result = append(result, url)
+		}
+	}
+	return result
+}
+
+// enqueueURLs queues every link as its own job and acknowledges them in one message
+func (b *Bot) enqueueURLs(msg *tgbotapi.Message, urls []string, lang string) {
+	chatID := msg.Chat.ID
+	if len(urls) > b.config.MaxLinksPerMessage*2 {
+		urls = urls[:b.config.MaxLinksPerMessage*2] // Bound the number of short links resolved per message
+	}
+	urls = canonicalURLs(urls)
+	total := len(urls)
+	if total > b.config.MaxLinksPerMessage {
+		urls = urls[:b.config.MaxLinksPerMessage]
+	}
+	if limitText, ok := b.allowJobs(chatID, len(urls), lang); !ok {
+		b.reply(msg, limitText)
+		return
+	}
+
+	queued := 0
+	for _, url := range urls {
+		job := b.defaultJob(chatID, url, lang)
+		job.ReplyTo = replyTarget(msg)
+		if err := b.queue.Enqueue(job); err != nil {
+			continue
+		}
+		queued++
+	}
+
+	if queued == 0 {
+		b.reply(msg, i18n.T(lang, "queue.enqueue_many_failed"))
+		return
+	}
+	text := i18n.T(lang, "url.queued_many", queued, len(urls))
+	if total > len(urls) {
+		text += i18n.T(lang, "url.skipped", len(urls), total-len(urls))
+	}
+	b.reply(msg, text)
+}
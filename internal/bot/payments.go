@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"envedour-bot/internal/config"
+	"envedour-bot/internal/donor"
+	"envedour-bot/internal/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// starsCurrency is the currency code of Telegram Stars. Stars invoices
+// need no payment provider, so the provider token stays empty.
+const starsCurrency = "XTR"
+
+// priorityPayloadPrefix marks invoices for priority access, followed by the number of days
+const priorityPayloadPrefix = "priority:"
+
+// priorityPlan returns the configured plan for the number of days
+func (b *Bot) priorityPlan(days int) (config.PriorityPlan, bool) {
+	for _, plan := range b.config.PriorityPlans {
+		if plan.Days == days {
+			return plan, true
+		}
+	}
+	return config.PriorityPlan{}, false
+}
+
+// showPriorityPlans offers the priority plans for Stars
+func (b *Bot) showPriorityPlans(msg *tgbotapi.Message, lang string) {
+	if len(b.config.PriorityPlans) == 0 {
+		b.reply(msg, i18n.T(lang, "pay.unavailable"))
+		return
+	}
+	if !msg.Chat.IsPrivate() {
+		b.reply(msg, i18n.T(lang, "pay.private_only"))
+		return
+	}
+
+	text := i18n.T(lang, "pay.plans")
+	if d, _ := b.donors.Get(msg.Chat.ID); d != nil {
+		text += i18n.T(lang, "pay.active", formatDonorExpiry(lang, d))
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, plan := range b.config.PriorityPlans {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				i18n.T(lang, "pay.plan_button", plan.Days, plan.Stars),
+				fmt.Sprintf("buy_%d", plan.Days),
+			),
+		))
+	}
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.api.Send(reply)
+}
+
+// sendPriorityInvoice sends a Stars invoice for the plan
+func (b *Bot) sendPriorityInvoice(chatID int64, days int, lang string) {
+	plan, ok := b.priorityPlan(days)
+	if !ok {
+		b.sendMessage(chatID, i18n.T(lang, "pay.plan_gone"))
+		return
+	}
+
+	invoice := tgbotapi.NewInvoice(chatID,
+		i18n.T(lang, "pay.invoice_title", plan.Days),
+		i18n.T(lang, "pay.invoice_description"),
+		priorityPayloadPrefix+strconv.Itoa(plan.Days),
+		"", "", starsCurrency,
+		[]tgbotapi.LabeledPrice{{Label: i18n.T(lang, "pay.invoice_label", plan.Days), Amount: plan.Stars}},
+	)
+	invoice.SuggestedTipAmounts = []int{} // Stars have no tips, send an empty list instead of null
+	if _, err := b.api.Send(invoice); err != nil {
+		log.Printf("Failed to send invoice: %v", err)
+		b.sendMessage(chatID, i18n.T(lang, "pay.invoice_failed"))
+	}
+}
+
+// handlePreCheckout confirms the payment only if the invoice still matches a plan
+func (b *Bot) handlePreCheckout(query *tgbotapi.PreCheckoutQuery, lang string) {
+	answer := tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: query.ID, OK: true}
+
+	plan, ok := b.planForPayload(query.InvoicePayload)
+	if !ok || query.Currency != starsCurrency || query.TotalAmount != plan.Stars {
+		answer.OK = false
+		answer.ErrorMessage = i18n.T(lang, "pay.plan_changed")
+	}
+	if _, err := b.api.Request(answer); err != nil {
+		log.Printf("Failed to answer pre-checkout query: %v", err)
+	}
+}
+
+// handleSuccessfulPayment grants donor status for the paid plan
+func (b *Bot) handleSuccessfulPayment(msg *tgbotapi.Message, lang string) {
+	payment := msg.SuccessfulPayment
+	log.Printf("Payment from %d: %d %s, payload %q, charge %s",
+		msg.Chat.ID, payment.TotalAmount, payment.Currency, payment.InvoicePayload, payment.TelegramPaymentChargeID)
+
+	plan, ok := b.planForPayload(payment.InvoicePayload)
+	if !ok {
+		// The plan was checked at pre-checkout, the payload can't be unknown here
+		b.sendMessage(msg.Chat.ID, i18n.T(lang, "pay.plan_missing"))
+		return
+	}
+
+	fresh, err := b.donors.RecordPayment(donor.Payment{
+		ChargeID: payment.TelegramPaymentChargeID,
+		ChatID:   msg.Chat.ID,
+		Stars:    payment.TotalAmount,
+		Days:     plan.Days,
+		PaidAt:   time.Now(),
+	})
+	if err != nil {
+		log.Printf("Failed to record payment %s: %v", payment.TelegramPaymentChargeID, err)
+		b.sendMessage(msg.Chat.ID, i18n.T(lang, "pay.grant_failed"))
+		return
+	}
+	if !fresh {
+		log.Printf("Payment %s was already processed", payment.TelegramPaymentChargeID)
+		return
+	}
+
+	// Buying priority extends the status, it never downgrades premium donors
+	tier := donor.TierBasic
+	if current, _ := b.donors.Get(msg.Chat.ID); current != nil && current.Tier == donor.TierPremium {
+		tier = donor.TierPremium
+	}
+	d, err := b.donors.Grant(msg.Chat.ID, tier, time.Duration(plan.Days)*24*time.Hour, 0)
+	if err != nil {
+		log.Printf("Failed to grant paid priority to %d: %v", msg.Chat.ID, err)
+		b.donors.ForgetPayment(payment.TelegramPaymentChargeID)
+		b.sendMessage(msg.Chat.ID, i18n.T(lang, "pay.grant_failed"))
+		return
+	}
+	b.sendMessage(msg.Chat.ID, i18n.T(lang, "pay.thanks", formatDonorExpiry(lang, d)))
+}
+
+func (b *Bot) planForPayload(payload string) (config.PriorityPlan, bool) {
+	if !strings.HasPrefix(payload, priorityPayloadPrefix) {
+		return config.PriorityPlan{}, false
+	}
+	days, err := strconv.Atoi(strings.TrimPrefix(payload, priorityPayloadPrefix))
+	if err != nil {
+		return config.PriorityPlan{}, false
+	}
+	return b.priorityPlan(days)
+}
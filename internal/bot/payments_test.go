@@ -0,0 +1,259 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"envedour-bot/internal/config"
+	"envedour-bot/internal/donor"
+	"envedour-bot/internal/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// apiCall is a request the bot made to the fake Bot API
+type apiCall struct {
+	method string
+	params url.Values
+}
+
+// fakeBotAPI records Bot API calls and answers them with success
+type fakeBotAPI struct {
+	mu    sync.Mutex
+	calls []apiCall
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	var result string
+	switch method {
+	case "getMe":
+		result = `{"id": 1, "is_bot": true, "first_name": "Test", "username": "test_bot"}`
+	case "sendMessage", "sendInvoice":
+		f.mu.Lock()
+		f.calls = append(f.calls, apiCall{method, r.Form})
+		f.mu.Unlock()
+		result = fmt.Sprintf(`{"message_id": 1, "date": 0, "chat": {"id": %s, "type": "private"}}`, r.Form.Get("chat_id"))
+	default:
+		f.mu.Lock()
+		f.calls = append(f.calls, apiCall{method, r.Form})
+		f.mu.Unlock()
+		result = "true"
+	}
+	fmt.Fprintf(w, `{"ok": true, "result": %s}`, result)
+}
+
+// take returns the calls made since the last take
+func (f *fakeBotAPI) take() []apiCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := f.calls
+	f.calls = nil
+	return calls
+}
+
+// newPaymentsBot creates a bot with one 30 day plan for 100 Stars, talking
+// to a fake Bot API and a fake Redis
+func newPaymentsBot(t *testing.T) (*Bot, *fakeBotAPI) {
+	t.Helper()
+	fake := &fakeBotAPI{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	api, err := tgbotapi.NewBotAPIWithClient("TOKEN", server.URL+"/bot%s/%s", server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newFakeRedis(t)
+	b := &Bot{
+		api:        api,
+		config:     &config.Config{PriorityPlans: []config.PriorityPlan{{Days: 30, Stars: 100}}, DefaultLanguage: i18n.EN},
+		donors:     donor.NewStore(client, nil),
+		users:      NewUserStore(client),
+		workerPool: make(chan struct{}, 1),
+	}
+	return b, fake
+}
+
+func paymentMessage(chatID int64, chargeID string) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		Chat: &tgbotapi.Chat{ID: chatID, Type: "private"},
+		SuccessfulPayment: &tgbotapi.SuccessfulPayment{
+			Currency:                starsCurrency,
+			TotalAmount:             100,
+			InvoicePayload:          "priority:30",
+			TelegramPaymentChargeID: chargeID,
+		},
+	}
+}
+
+func TestPriorityPurchase(t *testing.T) {
+	b, fake := newPaymentsBot(t)
+	const chatID = 42
+
+	// Invoice
+	b.sendPriorityInvoice(chatID, 30, i18n.EN)
+	calls := fake.take()
+	if len(calls) != 1 || calls[0].method != "sendInvoice" {
+		t.Fatalf("calls = %+v, want one sendInvoice", calls)
+	}
+	invoice := calls[0].params
+	if invoice.Get("currency") != starsCurrency || invoice.Get("payload") != "priority:30" || invoice.Get("provider_token") != "" {
+		t.Errorf("invoice currency %q, payload %q, provider token %q", invoice.Get("currency"), invoice.Get("payload"), invoice.Get("provider_token"))
+	}
+	var prices []tgbotapi.LabeledPrice
+	if err := json.Unmarshal([]byte(invoice.Get("prices")), &prices); err != nil || len(prices) != 1 || prices[0].Amount != 100 {
+		t.Errorf("invoice prices %q, want a single price of 100", invoice.Get("prices"))
+	}
+
+	// Pre-checkout
+	b.handlePreCheckout(&tgbotapi.PreCheckoutQuery{
+		ID:             "q1",
+		Currency:       starsCurrency,
+		TotalAmount:    100,
+		InvoicePayload: "priority:30",
+	}, i18n.EN)
+	calls = fake.take()
+	if len(calls) != 1 || calls[0].method != "answerPreCheckoutQuery" || calls[0].params.Get("ok") != "true" {
+		t.Fatalf("pre-checkout calls = %+v, want ok", calls)
+	}
+
+	// Successful payment
+	b.handleSuccessfulPayment(paymentMessage(chatID, "charge1"), i18n.EN)
+	d, err := b.donors.Get(chatID)
+	if err != nil || d == nil {
+		t.Fatalf("no donor status after payment: %v", err)
+	}
+	if d.Tier != donor.TierBasic {
+		t.Errorf("tier = %q, want %q", d.Tier, donor.TierBasic)
+	}
+	if left := time.Until(d.ExpiresAt); left < 29*24*time.Hour || left > 30*24*time.Hour {
+		t.Errorf("status expires in %v, want 30 days", left)
+	}
+	calls = fake.take()
+	if len(calls) != 1 || calls[0].method != "sendMessage" || !strings.HasPrefix(calls[0].params.Get("text"), "💎") {
+		t.Errorf("calls = %+v, want the thanks message", calls)
+	}
+
+	// Telegram delivers the same payment again
+	b.handleSuccessfulPayment(paymentMessage(chatID, "charge1"), i18n.EN)
+	again, _ := b.donors.Get(chatID)
+	if again == nil || !again.ExpiresAt.Equal(d.ExpiresAt) {
+		t.Errorf("redelivered payment changed the status: %+v, was %+v", again, d)
+	}
+	if calls := fake.take(); len(calls) != 0 {
+		t.Errorf("redelivered payment answered with %+v", calls)
+	}
+
+	// A new payment extends the status
+	b.handleSuccessfulPayment(paymentMessage(chatID, "charge2"), i18n.EN)
+	extended, _ := b.donors.Get(chatID)
+	if extended == nil || !extended.ExpiresAt.Equal(d.ExpiresAt.Add(30*24*time.Hour)) {
+		t.Errorf("second payment: %+v, want expiry %v", extended, d.ExpiresAt.Add(30*24*time.Hour))
+	}
+}
+
+func TestPreCheckoutRejectsChangedPlan(t *testing.T) {
+	b, fake := newPaymentsBot(t)
+
+	for _, query := range []tgbotapi.PreCheckoutQuery{
+		{ID: "price", Currency: starsCurrency, TotalAmount: 50, InvoicePayload: "priority:30"},
+		{ID: "plan", Currency: starsCurrency, TotalAmount: 100, InvoicePayload: "priority:7"},
+		{ID: "currency", Currency: "USD", TotalAmount: 100, InvoicePayload: "priority:30"},
+	} {
+		b.handlePreCheckout(&query, i18n.EN)
+		// The library leaves out ok=false, the error message marks the rejection
+		calls := fake.take()
+		if len(calls) != 1 || calls[0].params.Get("ok") == "true" || calls[0].params.Get("error_message") == "" {
+			t.Errorf("%s: calls = %+v, want a rejection", query.ID, calls)
+		}
+	}
+}
+
+func TestPurchaseKeepsPremium(t *testing.T) {
+	b, _ := newPaymentsBot(t)
+	const chatID = 42
+
+	if _, err := b.donors.Grant(chatID, donor.TierPremium, 24*time.Hour, 1); err != nil {
+		t.Fatal(err)
+	}
+	b.handleSuccessfulPayment(paymentMessage(chatID, "charge1"), i18n.EN)
+
+	d, _ := b.donors.Get(chatID)
+	if d == nil || d.Tier != donor.TierPremium {
+		t.Fatalf("donor after purchase = %+v, want premium", d)
+	}
+	if left := time.Until(d.ExpiresAt); left < 30*24*time.Hour {
+		t.Errorf("status expires in %v, want 31 days", left)
+	}
+}
+
+// A user banned between the invoice and the payment has paid already,
+// the payment must still go through
+func TestBannedUserPayment(t *testing.T) {
+	b, fake := newPaymentsBot(t)
+	const chatID = 42
+	user := &tgbotapi.User{ID: chatID}
+
+	if _, err := b.users.Ban(chatID); err != nil || !b.isBanned(chatID) {
+		t.Fatalf("failed to ban: %v", err)
+	}
+
+	b.workerPool <- struct{}{} // handleUpdate releases a worker
+	b.handleUpdate(tgbotapi.Update{PreCheckoutQuery: &tgbotapi.PreCheckoutQuery{
+		ID:             "q1",
+		From:           user,
+		Currency:       starsCurrency,
+		TotalAmount:    100,
+		InvoicePayload: "priority:30",
+	}})
+	calls := fake.take()
+	if len(calls) != 1 || calls[0].method != "answerPreCheckoutQuery" || calls[0].params.Get("ok") != "true" {
+		t.Fatalf("pre-checkout calls = %+v, want ok", calls)
+	}
+
+	msg := paymentMessage(chatID, "charge1")
+	msg.From = user
+	b.workerPool <- struct{}{}
+	b.handleUpdate(tgbotapi.Update{Message: msg})
+
+	if d, _ := b.donors.Get(chatID); d == nil {
+		t.Fatal("banned user paid but got no donor status")
+	}
+	calls = fake.take()
+	if len(calls) != 1 || !strings.HasPrefix(calls[0].params.Get("text"), "💎") {
+		t.Errorf("calls = %+v, want the thanks message", calls)
+	}
+}
+
+// Donors from DONOR_CHAT_IDS keep a premium tier granted on top
+func TestStaticDonorPremium(t *testing.T) {
+	b, _ := newPaymentsBot(t)
+	const chatID = 42
+	b.donors = donor.NewStore(newFakeRedis(t), []int64{chatID})
+
+	b.grantDonor(1, []string{"42", "30", donor.TierPremium}, i18n.EN)
+	d, _ := b.donors.Get(chatID)
+	if d == nil || !d.Static || d.Tier != donor.TierPremium || !d.Permanent() {
+		t.Fatalf("static donor after premium grant = %+v, want permanent premium", d)
+	}
+
+	b.handleSuccessfulPayment(paymentMessage(chatID, "charge1"), i18n.EN)
+	if d, _ := b.donors.Get(chatID); d == nil || d.Tier != donor.TierPremium {
+		t.Errorf("static donor after purchase = %+v, want premium", d)
+	}
+
+	b.revokeDonor([]string{"42"}, i18n.EN)
+	if d, _ := b.donors.Get(chatID); d == nil || d.Tier != donor.TierBasic {
+		t.Errorf("static donor after revoke = %+v, want basic", d)
+	}
+}
@@ -10,8 +10,44 @@ import (
 )
 
 type UserPreferences struct {
-	Quality   string `json:"quality"`   // "best", "1080p", "720p", "480p", "360p", "audio"
-	MediaType string `json:"media_type"` // "video" or "audio"
+	Quality      string `json:"quality"`       // "best", "1080p", "720p", "480p", "360p", "audio"
+	MediaType    string `json:"media_type"`    // "video" or "audio"
+	AudioFormat  string `json:"audio_format"`  // "mp3", "m4a", "opus", "ogg", "flac"
+	AudioBitrate string `json:"audio_bitrate"` // kbit/s or "best"
+	AudioTags    bool   `json:"audio_tags"`    // Embed tags and cover art into audio
+	VoiceAudio   bool   `json:"voice_audio"`   // Send audio as voice messages
+	RoundVideo   bool   `json:"round_video"`   // Send video as round video notes
+	SubMode      string `json:"sub_mode"`      // "" (off), "file" or "burn"
+	SubLang      string `json:"sub_lang"`      // Subtitle language code
+	SubAuto      bool   `json:"sub_auto"`      // Allow auto-generated subtitles
+	SponsorBlock bool   `json:"sponsorblock"`  // Cut sponsor segments from YouTube videos
+	Language     string `json:"language"`      // Chosen with /lang, "" follows the Telegram language
+
+	GroupAutoDownload bool `json:"group_auto_download"` // Groups: download links without a mention
+}
+
+// delivery returns the job delivery mode for the media type
+func (p *UserPreferences) delivery(mediaType string) string {
+	switch {
+	case mediaType == "audio" && p.VoiceAudio:
+		return "voice"
+	case mediaType == "video" && p.RoundVideo:
+		return "video_note"
+	}
+	return ""
+}
+
+// defaultPreferences returns preferences for users who haven't changed anything
+func defaultPreferences() *UserPreferences {
+	return &UserPreferences{
+		Quality:      "best",
+		MediaType:    "video",
+		AudioFormat:  "mp3",
+		AudioBitrate: "best",
+		AudioTags:    true,
+		SubLang:      "ru",
+		SubAuto:      true,
+	}
 }
 
 type PreferencesStore struct {
@@ -31,31 +67,46 @@ func (p *PreferencesStore) GetPreferences(chatID int64) *UserPreferences {
 	data, err := p.client.Get(p.ctx, key).Result()
 	if err != nil {
 		// Return defaults
-		return &UserPreferences{
-			Quality:   "best",
-			MediaType: "video",
-		}
+		return defaultPreferences()
 	}
 
-	var prefs UserPreferences
-	if err := json.Unmarshal([]byte(data), &prefs); err != nil {
-		return &UserPreferences{
-			Quality:   "best",
-			MediaType: "video",
-		}
+	// Start from defaults so fields added later get sane values
+	prefs := defaultPreferences()
+	if err := json.Unmarshal([]byte(data), prefs); err != nil {
+		return defaultPreferences()
+	}
+	if prefs.AudioFormat == "" {
+		prefs.AudioFormat = "mp3"
 	}
+	if prefs.AudioBitrate == "" {
+		prefs.AudioBitrate = "best"
+	}
+	// GIF used to be a setting, now it's a one-off choice
+	if prefs.Quality == "gif" {
+		prefs.Quality, prefs.MediaType = "best", "video"
+	}
+
+	return prefs
+}
 
-	return &prefs
+// mediaTypeForQuality returns the media type a quality option produces
+func mediaTypeForQuality(quality string) string {
+	switch quality {
+	case "audio", "chapters":
+		return "audio"
+	case "gif":
+		return "animation"
+	}
+	return "video"
 }
 
 func (p *PreferencesStore) SetQuality(chatID int64, quality string) error {
+	if quality == "gif" {
+		return fmt.Errorf("GIF is a one-off choice, not a setting")
+	}
 	prefs := p.GetPreferences(chatID)
 	prefs.Quality = quality
-	if quality == "audio" {
-		prefs.MediaType = "audio"
-	} else {
-		prefs.MediaType = "video"
-	}
+	prefs.MediaType = mediaTypeForQuality(quality)
 	return p.SavePreferences(chatID, prefs)
 }
 
@@ -64,10 +115,76 @@ func (p *PreferencesStore) SetMediaType(chatID int64, mediaType string) error {
 	prefs.MediaType = mediaType
 	if mediaType == "audio" {
 		prefs.Quality = "audio"
+	} else if prefs.Quality == "audio" {
+		prefs.Quality = "best"
+	}
+	return p.SavePreferences(chatID, prefs)
+}
+
+func (p *PreferencesStore) SetAudioFormat(chatID int64, format string) error {
+	prefs := p.GetPreferences(chatID)
+	prefs.AudioFormat = format
+	return p.SavePreferences(chatID, prefs)
+}
+
+func (p *PreferencesStore) SetAudioBitrate(chatID int64, bitrate string) error {
+	prefs := p.GetPreferences(chatID)
+	prefs.AudioBitrate = bitrate
+	return p.SavePreferences(chatID, prefs)
+}
+
+func (p *PreferencesStore) ToggleAudioTags(chatID int64) error {
+	prefs := p.GetPreferences(chatID)
+	prefs.AudioTags = !prefs.AudioTags
+	return p.SavePreferences(chatID, prefs)
+}
+
+func (p *PreferencesStore) ToggleVoiceAudio(chatID int64) error {
+	prefs := p.GetPreferences(chatID)
+	prefs.VoiceAudio = !prefs.VoiceAudio
+	return p.SavePreferences(chatID, prefs)
+}
+
+func (p *PreferencesStore) ToggleRoundVideo(chatID int64) error {
+	prefs := p.GetPreferences(chatID)
+	prefs.RoundVideo = !prefs.RoundVideo
+	return p.SavePreferences(chatID, prefs)
+}
+
+func (p *PreferencesStore) SetSubtitles(chatID int64, mode, lang string) error {
+	prefs := p.GetPreferences(chatID)
+	prefs.SubMode = mode
+	if lang != "" {
+		prefs.SubLang = lang
 	}
 	return p.SavePreferences(chatID, prefs)
 }
 
+func (p *PreferencesStore) ToggleSubAuto(chatID int64) error {
+	prefs := p.GetPreferences(chatID)
+	prefs.SubAuto = !prefs.SubAuto
+	return p.SavePreferences(chatID, prefs)
+}
+
+func (p *PreferencesStore) ToggleSponsorBlock(chatID int64) error {
+	prefs := p.GetPreferences(chatID)
+	prefs.SponsorBlock = !prefs.SponsorBlock
+	return p.SavePreferences(chatID, prefs)
+}
+
+// SetLanguage stores the language chosen with /lang, "" switches back to auto
+func (p *PreferencesStore) SetLanguage(chatID int64, lang string) error {
+	prefs := p.GetPreferences(chatID)
+	prefs.Language = lang
+	return p.SavePreferences(chatID, prefs)
+}
+
+func (p *PreferencesStore) ToggleGroupAutoDownload(chatID int64) error {
+	prefs := p.GetPreferences(chatID)
+	prefs.GroupAutoDownload = !prefs.GroupAutoDownload
+	return p.SavePreferences(chatID, prefs)
+}
+
 func (p *PreferencesStore) SavePreferences(chatID int64, prefs *UserPreferences) error {
 	key := fmt.Sprintf("prefs:%d", chatID)
 	data, err := json.Marshal(prefs)
@@ -77,20 +194,37 @@ func (p *PreferencesStore) SavePreferences(chatID int64, prefs *UserPreferences)
 	return p.client.Set(p.ctx, key, data, 30*24*time.Hour).Err() // 30 days expiry
 }
 
-// SavePendingURL saves a URL temporarily while user selects quality
-func (p *PreferencesStore) SavePendingURL(jobID, url string) error {
+// PendingDownload is a link waiting for the user to pick a quality
+type PendingDownload struct {
+	URL       string  `json:"url"`
+	ClipStart float64 `json:"clip_start,omitempty"`
+	ClipEnd   float64 `json:"clip_end,omitempty"`
+}
+
+// SavePendingDownload saves a link temporarily while user selects quality
+func (p *PreferencesStore) SavePendingDownload(jobID string, pending *PendingDownload) error {
 	key := fmt.Sprintf("pending_url:%s", jobID)
-	return p.client.Set(p.ctx, key, url, 10*time.Minute).Err() // 10 minutes expiry
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	return p.client.Set(p.ctx, key, data, 10*time.Minute).Err() // 10 minutes expiry
 }
 
-// GetPendingURL retrieves a temporarily saved URL
-func (p *PreferencesStore) GetPendingURL(jobID string) (string, error) {
+// GetPendingDownload retrieves a temporarily saved link
+func (p *PreferencesStore) GetPendingDownload(jobID string) (*PendingDownload, error) {
 	key := fmt.Sprintf("pending_url:%s", jobID)
-	url, err := p.client.Get(p.ctx, key).Result()
+	data, err := p.client.Get(p.ctx, key).Result()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	// Delete after retrieval
 	p.client.Del(p.ctx, key)
-	return url, nil
+
+	var pending PendingDownload
+	if err := json.Unmarshal([]byte(data), &pending); err != nil {
+		// Entries saved before clipping support hold the bare URL
+		pending = PendingDownload{URL: data}
+	}
+	return &pending, nil
 }
@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"errors"
+	"time"
+
+	"envedour-bot/internal/donor"
+	"envedour-bot/internal/i18n"
+	"envedour-bot/internal/ratelimit"
+)
+
+// limits returns the rate limits for the chat, donors get higher ones
+// and premium donors the highest
+func (b *Bot) limits(chatID int64) ratelimit.Limits {
+	switch b.donors.Tier(chatID) {
+	case donor.TierPremium:
+		return ratelimit.Limits{
+			RequestsPerMinute: b.config.RatePremiumRequestsPerMinute,
+			JobsPerDay:        b.config.RatePremiumJobsPerDay,
+			BytesPerDay:       b.config.RatePremiumBytesPerDay,
+		}
+	case donor.TierBasic:
+		return ratelimit.Limits{
+			RequestsPerMinute: b.config.RateDonorRequestsPerMinute,
+			JobsPerDay:        b.config.RateDonorJobsPerDay,
+			BytesPerDay:       b.config.RateDonorBytesPerDay,
+		}
+	}
+	return ratelimit.Limits{
+		RequestsPerMinute: b.config.RateRequestsPerMinute,
+		JobsPerDay:        b.config.RateJobsPerDay,
+		BytesPerDay:       b.config.RateBytesPerDay,
+	}
+}
+
+// allowRequest takes a request from the chat's per-minute limit. Returns
+// a message for the user if the limit is exceeded.
+func (b *Bot) allowRequest(chatID int64, lang string) (string, bool) {
+	if b.limiter == nil {
+		return "", true
+	}
+	if err := b.limiter.AllowRequest(chatID, b.limits(chatID)); err != nil {
+		return b.limitMessage(chatID, lang, err), false
+	}
+	return "", true
+}
+
+// allowJobs reserves n jobs from the chat's daily quota. Returns a message
+// for the user if the quota is used up.
+func (b *Bot) allowJobs(chatID int64, n int, lang string) (string, bool) {
+	if b.limiter == nil {
+		return "", true
+	}
+	if err := b.limiter.AllowJobs(chatID, n, b.limits(chatID)); err != nil {
+		return b.limitMessage(chatID, lang, err), false
+	}
+	return "", true
+}
+
+func (b *Bot) limitMessage(chatID int64, lang string, err error) string {
+	var limitErr *ratelimit.LimitError
+	if !errors.As(err, &limitErr) {
+		return i18n.T(lang, "limit.check_failed")
+	}
+
+	var text string
+	switch limitErr.Kind {
+	case ratelimit.KindRequests:
+		text = i18n.T(lang, "limit.requests", limitErr.Limit, formatWait(lang, limitErr.RetryAfter))
+	case ratelimit.KindJobs:
+		text = i18n.T(lang, "limit.jobs", limitErr.Limit, formatWait(lang, limitErr.RetryAfter))
+	default:
+		text = i18n.T(lang, "limit.bytes", limitErr.Limit/1024/1024, formatWait(lang, limitErr.RetryAfter))
+	}
+	if !b.isDonor(chatID) {
+		text += i18n.T(lang, "limit.donors_higher")
+	}
+	return text
+}
+
+// formatWait renders a wait time as "2 ч 5 мин", "12 мин" or "30 сек"
+func formatWait(lang string, d time.Duration) string {
+	s := int(d.Round(time.Second).Seconds())
+	switch {
+	case s >= 3600:
+		return i18n.T(lang, "wait.hours", s/3600, s%3600/60)
+	case s >= 60:
+		return i18n.T(lang, "wait.minutes", (s+59)/60)
+	case s < 1:
+		s = 1
+	}
+	return i18n.T(lang, "duration.sec", s)
+}
@@ -0,0 +1,174 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"envedour-bot/internal/executor"
+	"envedour-bot/internal/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// searchButtonTitleLen caps result titles so buttons stay readable
+const searchButtonTitleLen = 48
+
+// soundcloudSearchPrefixes switch a text search from YouTube to SoundCloud
+var soundcloudSearchPrefixes = []string{"sc ", "soundcloud "}
+
+// parseSearchQuery strips the SoundCloud prefix from a search query
+func parseSearchQuery(text string) (query string, soundcloud bool) {
+	lower := strings.ToLower(text)
+	for _, prefix := range soundcloudSearchPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return strings.TrimSpace(text[len(prefix):]), true
+		}
+	}
+	return text, false
+}
+
+// handleSearch treats plain text as a search query and shows the top results
+func (b *Bot) handleSearch(msg *tgbotapi.Message, text, lang string) {
+	chatID := msg.Chat.ID
+	query, soundcloud := parseSearchQuery(text)
+	if utf8.RuneCountInString(query) < 2 {
+		b.reply(msg, i18n.T(lang, "search.too_short"))
+		return
+	}
+	if b.preferences == nil {
+		b.reply(msg, i18n.T(lang, "search.unavailable"))
+		return
+	}
+
+	searching := tgbotapi.NewMessage(chatID, i18n.T(lang, "search.searching", query))
+	searching.ReplyToMessageID = replyTarget(msg)
+	status, err := b.api.Send(searching)
+	if err != nil {
+		return
+	}
+
+	results, err := b.executor.Search(context.Background(), query, soundcloud)
+	if err != nil {
+		log.Printf("Search for %q failed: %v", query, err)
+		b.api.Send(tgbotapi.NewEditMessageText(chatID, status.MessageID, i18n.T(lang, "search.failed")))
+		return
+	}
+	if len(results) == 0 {
+		b.api.Send(tgbotapi.NewEditMessageText(chatID, status.MessageID, i18n.T(lang, "search.nothing", query)))
+		return
+	}
+
+	searchID := generateJobID()
+	if err := b.preferences.SaveSearchResults(searchID, results); err != nil {
+		b.api.Send(tgbotapi.NewEditMessageText(chatID, status.MessageID, i18n.T(lang, "search.failed")))
+		return
+	}
+
+	keyboard := createSearchResultsKeyboard(searchID, results)
+	edit := tgbotapi.NewEditMessageText(chatID, status.MessageID, i18n.T(lang, "search.results", query))
+	edit.ReplyMarkup = &keyboard
+	b.api.Send(edit)
+}
+
+// downloadSearchResult enqueues the chosen result with the user's default preferences
+func (b *Bot) downloadSearchResult(chatID int64, replyTo int, searchID string, index int, lang string) {
+	if b.preferences == nil {
+		b.sendMessage(chatID, i18n.T(lang, "search.retry_unavailable"))
+		return
+	}
+	results, err := b.preferences.GetSearchResults(searchID)
+	if err != nil || index < 0 || index >= len(results) {
+		b.sendMessage(chatID, i18n.T(lang, "search.expired"))
+		return
+	}
+
+	if limitText, ok := b.allowJobs(chatID, 1, lang); !ok {
+		b.sendMessage(chatID, limitText)
+		return
+	}
+
+	result := results[index]
+	job := b.defaultJob(chatID, result.URL, lang)
+	job.ReplyTo = replyTo
+	if err := b.queue.Enqueue(job); err != nil {
+		b.sendMessage(chatID, i18n.T(lang, "queue.enqueue_failed"))
+		return
+	}
+	b.sendMessage(chatID, i18n.T(lang, "search.queued", result.Title))
+}
+
+// formatSearchResult renders a result as a button label, e.g. "Title (3:45)"
+func formatSearchResult(result executor.SearchResult) string {
+	title := result.Title
+	if utf8.RuneCountInString(title) > searchButtonTitleLen {
+		title = string([]rune(title)[:searchButtonTitleLen-1]) + "…"
+	}
+	if result.Duration > 0 {
+		return fmt.Sprintf("%s (%s)", title, formatTimestamp(result.Duration))
+	}
+	return title
+}
+
+// SaveSearchResults keeps search results while the user picks one
+func (p *PreferencesStore) SaveSearchResults(searchID string, results []executor.SearchResult) error {
+	key := fmt.Sprintf("search:%s", searchID)
+	data, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	return p.client.Set(p.ctx, key, data, 10*time.Minute).Err() // 10 minutes expiry
+}
+
+// GetSearchResults retrieves saved search results. They are kept until
+// expiry so several results can be picked from one search.
+func (p *PreferencesStore) GetSearchResults(searchID string) ([]executor.SearchResult, error) {
+	key := fmt.Sprintf("search:%s", searchID)
+	data, err := p.client.Get(p.ctx, key).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var results []executor.SearchResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// searchCacheKey keys cached results by source and normalized query
+func searchCacheKey(query string, soundcloud bool) string {
+	source := "yt"
+	if soundcloud {
+		source = "sc"
+	}
+	return fmt.Sprintf("search_cache:%s:%s", source, strings.ToLower(query))
+}
+
+// SaveCachedSearch remembers the results of a search for ttl so repeated
+// queries don't run yt-dlp again
+func (p *PreferencesStore) SaveCachedSearch(query string, soundcloud bool, results []executor.SearchResult, ttl time.Duration) {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return
+	}
+	if err := p.client.Set(p.ctx, searchCacheKey(query, soundcloud), data, ttl).Err(); err != nil {
+		log.Printf("Failed to cache search results: %v", err)
+	}
+}
+
+// GetCachedSearch returns the results of a recent identical search
+func (p *PreferencesStore) GetCachedSearch(query string, soundcloud bool) ([]executor.SearchResult, bool) {
+	data, err := p.client.Get(p.ctx, searchCacheKey(query, soundcloud)).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	var results []executor.SearchResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, false
+	}
+	return results, true
+}
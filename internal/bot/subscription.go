@@ -0,0 +1,118 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"envedour-bot/internal/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// How long getChatMember answers are reused. Members are cached longer,
+// so those who just subscribed don't wait for a stale "no".
+const (
+	subscribedCacheTTL    = time.Hour
+	notSubscribedCacheTTL = time.Minute
+)
+
+// requireSubscription reports whether the user may download. Users who
+// aren't subscribed to REQUIRED_CHANNEL get a reply with the subscribe
+// keyboard. Donors and admins bypass the gate.
+func (b *Bot) requireSubscription(msg *tgbotapi.Message, lang string) bool {
+	if msg.From == nil || !b.needsSubscription(msg.From.ID) {
+		return true
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, i18n.T(lang, "sub.required"))
+	reply.ReplyMarkup = createSubscribeKeyboard(lang, b.config.RequiredChannelLink)
+	reply.ReplyToMessageID = replyTarget(msg)
+	b.api.Send(reply)
+	return false
+}
+
+// needsSubscription reports whether the user has to subscribe before downloading
+func (b *Bot) needsSubscription(userID int64) bool {
+	if b.config.RequiredChannel == "" || b.isAdmin(userID) || b.isDonor(userID) {
+		return false
+	}
+	return !b.isSubscribed(userID, false)
+}
+
+// isSubscribed checks the user's membership in REQUIRED_CHANNEL. fresh
+// skips the cache. If the membership can't be checked, e.g. the bot isn't
+// an admin of the channel, the user is let through.
+func (b *Bot) isSubscribed(userID int64, fresh bool) bool {
+	if !fresh && b.preferences != nil {
+		if subscribed, ok := b.preferences.GetSubscription(userID); ok {
+			return subscribed
+		}
+	}
+
+	member, err := b.api.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: b.requiredChannelUser(userID),
+	})
+	if err != nil {
+		log.Printf("Failed to check subscription of %d to %s: %v", userID, b.config.RequiredChannel, err)
+		return true
+	}
+
+	subscribed := member.IsCreator() || member.IsAdministrator() || member.Status == "member" ||
+		(member.Status == "restricted" && member.IsMember)
+	if b.preferences != nil {
+		ttl := notSubscribedCacheTTL
+		if subscribed {
+			ttl = subscribedCacheTTL
+		}
+		b.preferences.SaveSubscription(userID, subscribed, ttl)
+	}
+	return subscribed
+}
+
+// requiredChannelUser addresses the user in REQUIRED_CHANNEL, which is
+// either "@username" or a numeric chat ID
+func (b *Bot) requiredChannelUser(userID int64) tgbotapi.ChatConfigWithUser {
+	channel := b.config.RequiredChannel
+	if strings.HasPrefix(channel, "@") {
+		return tgbotapi.ChatConfigWithUser{SuperGroupUsername: channel, UserID: userID}
+	}
+	chatID, _ := strconv.ParseInt(channel, 10, 64)
+	return tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: userID}
+}
+
+// checkSubscriptionAgain handles the "Check again" button
+func (b *Bot) checkSubscriptionAgain(query *tgbotapi.CallbackQuery, lang string) {
+	if !b.isSubscribed(query.From.ID, true) {
+		b.api.Request(tgbotapi.NewCallbackWithAlert(query.ID, i18n.T(lang, "sub.not_found")))
+		return
+	}
+	b.api.Request(tgbotapi.NewCallback(query.ID, i18n.T(lang, "sub.confirmed")))
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, i18n.T(lang, "sub.thanks"))
+	b.api.Send(edit)
+}
+
+func subscriptionKey(userID int64) string {
+	return fmt.Sprintf("sub:%d", userID)
+}
+
+// GetSubscription returns the cached membership in REQUIRED_CHANNEL.
+// ok is false if there is nothing cached.
+func (p *PreferencesStore) GetSubscription(userID int64) (subscribed, ok bool) {
+	value, err := p.client.Get(p.ctx, subscriptionKey(userID)).Result()
+	if err != nil {
+		return false, false
+	}
+	return value == "1", true
+}
+
+// SaveSubscription caches the membership in REQUIRED_CHANNEL
+func (p *PreferencesStore) SaveSubscription(userID int64, subscribed bool, ttl time.Duration) error {
+	value := "0"
+	if subscribed {
+		value = "1"
+	}
+	return p.client.Set(p.ctx, subscriptionKey(userID), value, ttl).Err()
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis sets with chat IDs
+const (
+	knownUsersKey  = "users:known"  // Private chats that ever wrote to the bot, for broadcasts
+	bannedUsersKey = "users:banned" // Users whose updates are ignored
+)
+
+// UserStore keeps track of the bot's users and bans. client may be nil,
+// then nobody is remembered or banned.
+type UserStore struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+func NewUserStore(client *redis.Client) *UserStore {
+	return &UserStore{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+// Remember adds the private chat to the known users
+func (u *UserStore) Remember(chatID int64) {
+	if u.client != nil {
+		u.client.SAdd(u.ctx, knownUsersKey, chatID)
+	}
+}
+
+// Forget removes a chat that blocked the bot or was deleted
+func (u *UserStore) Forget(chatID int64) {
+	if u.client != nil {
+		u.client.SRem(u.ctx, knownUsersKey, chatID)
+	}
+}
+
+// Known returns all known users
+func (u *UserStore) Known() ([]int64, error) {
+	return u.members(knownUsersKey)
+}
+
+// KnownCount returns the number of known users
+func (u *UserStore) KnownCount() int64 {
+	if u.client == nil {
+		return 0
+	}
+	return u.client.SCard(u.ctx, knownUsersKey).Val()
+}
+
+// Ban makes the bot ignore the user. Returns false if already banned.
+func (u *UserStore) Ban(userID int64) (bool, error) {
+	if u.client == nil {
+		return false, fmt.Errorf("user store is not available")
+	}
+	added, err := u.client.SAdd(u.ctx, bannedUsersKey, userID).Result()
+	return added > 0, err
+}
+
+// Unban lifts the ban. Returns false if the user wasn't banned.
+func (u *UserStore) Unban(userID int64) (bool, error) {
+	if u.client == nil {
+		return false, fmt.Errorf("user store is not available")
+	}
+	removed, err := u.client.SRem(u.ctx, bannedUsersKey, userID).Result()
+	return removed > 0, err
+}
+
+// IsBanned reports whether the user is banned
+func (u *UserStore) IsBanned(userID int64) bool {
+	if u.client == nil {
+		return false
+	}
+	return u.client.SIsMember(u.ctx, bannedUsersKey, userID).Val()
+}
+
+// Banned returns all banned users
+func (u *UserStore) Banned() ([]int64, error) {
+	return u.members(bannedUsersKey)
+}
+
+// members returns the set's chat IDs in ascending order
+func (u *UserStore) members(key string) ([]int64, error) {
+	if u.client == nil {
+		return nil, nil
+	}
+	values, err := u.client.SMembers(u.ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(values))
+	for _, v := range values {
+		if id, err := strconv.ParseInt(v, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
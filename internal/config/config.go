@@ -1,28 +1,64 @@
 package config
 
 import (
-	"fmt"
+	"errors"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	"envedour-bot/internal/i18n"
+
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	BotToken         string
-	RedisAddr        string
-	WorkerCount      int
-	TmpfsPath        string
-	MaxFileSize      int64
-	LocalAPIURL      string
-	DonorChatIDs     []int64
-	CookiesFile      string // Deprecated: use platform-specific cookies files
-	TikTokCookies    string // Path to TikTok cookies file (Netscape format)
-	InstagramCookies string // Path to Instagram cookies file (Netscape format)
-	YouTubeCookies   string // Path to YouTube cookies file (Netscape format)
-	MinFreeMemMB     int    // Minimum free memory in MB (default: 256)
+	BotToken              string
+	RedisAddr             string
+	WorkerCount           int
+	TmpfsPath             string
+	MaxFileSize           int64
+	LocalAPIURL           string
+	DonorChatIDs          []int64 // Permanent donors, more can be granted at runtime
+	AdminChatIDs          []int64
+	DefaultLanguage       string // Language for users whose Telegram language isn't supported
+	AccessMode            string // "open", "allowlist", "denylist" or "invite"
+	RequiredChannel       string // "@username" or chat ID users must subscribe to, empty disables the gate
+	RequiredChannelLink   string // Link for the subscribe button, derived from @username if empty
+	CookiesFile           string // Deprecated: use platform-specific cookies files
+	TikTokCookies         string // Path to TikTok cookies file (Netscape format)
+	InstagramCookies      string // Path to Instagram cookies file (Netscape format)
+	YouTubeCookies        string // Path to YouTube cookies file (Netscape format)
+	MinFreeMemMB          int    // Minimum free memory in MB (default: 256)
+	VoiceMaxSeconds       int    // Maximum length of audio sent as a voice message
+	VideoNoteMaxSeconds   int    // Maximum length of video sent as a round video note
+	AnimationMaxSeconds   int    // Clips converted to GIF animations are cut to this length
+	AnimationMaxWidth     int    // Animations are scaled down to this width in pixels
+	SponsorBlockAPI       string // SponsorBlock API URL, empty uses the yt-dlp default
+	LiveMaxMinutes        int    // Maximum live stream recording length
+	LiveDonorMaxMinutes   int    // Maximum live stream recording length for donors
+	LivePremiumMaxMinutes int    // Maximum live stream recording length for premium donors
+	InlineChatID          int64  // Chat where inline mode uploads files, 0 uses the user's chat
+	MaxLinksPerMessage    int    // Links beyond this number in one message are ignored
+
+	PriorityPlans []PriorityPlan // Priority access sold for Telegram Stars, empty disables payments
+
+	// Per-chat limits, 0 disables a limit
+	RateRequestsPerMinute        int
+	RateJobsPerDay               int
+	RateBytesPerDay              int64
+	RateDonorRequestsPerMinute   int
+	RateDonorJobsPerDay          int
+	RateDonorBytesPerDay         int64
+	RatePremiumRequestsPerMinute int
+	RatePremiumJobsPerDay        int
+	RatePremiumBytesPerDay       int64
+}
+
+// PriorityPlan is a number of days of donor status sold for Stars
+type PriorityPlan struct {
+	Days  int
+	Stars int
 }
 
 func Load() (*Config, error) {
@@ -42,33 +78,75 @@ func Load() (*Config, error) {
 	godotenv.Load(envPath)
 
 	cfg := &Config{
-		BotToken:         getEnv("BOT_TOKEN", ""),
-		RedisAddr:        getEnv("REDIS_ADDR", "localhost:6379"),
-		WorkerCount:      getEnvInt("WORKER_COUNT", 4),
-		TmpfsPath:        getEnv("TMPFS_PATH", "/dev/shm/videos"),
-		MaxFileSize:      int64(getEnvInt("MAX_FILE_SIZE_MB", 2048)) * 1024 * 1024,
-		LocalAPIURL:      getEnv("LOCAL_API_URL", "http://localhost:8089"),
-		DonorChatIDs:     parseChatIDs(getEnv("DONOR_CHAT_IDS", "")),
-		CookiesFile:      getEnv("COOKIES_FILE", ""),        // Deprecated: for backward compatibility
-		TikTokCookies:    getEnv("TIKTOK_COOKIES", ""),      // Path to TikTok cookies file
-		InstagramCookies: getEnv("INSTAGRAM_COOKIES", ""),   // Path to Instagram cookies file
-		YouTubeCookies:   getEnv("YOUTUBE_COOKIES", ""),     // Path to YouTube cookies file
-		MinFreeMemMB:     getEnvInt("MIN_FREE_MEM_MB", 256), // Minimum free memory in MB
+		BotToken:              getEnv("BOT_TOKEN", ""),
+		RedisAddr:             getEnv("REDIS_ADDR", "localhost:6379"),
+		WorkerCount:           getEnvInt("WORKER_COUNT", 4),
+		TmpfsPath:             getEnv("TMPFS_PATH", "/dev/shm/videos"),
+		MaxFileSize:           int64(getEnvInt("MAX_FILE_SIZE_MB", 2048)) * 1024 * 1024,
+		LocalAPIURL:           getEnv("LOCAL_API_URL", "http://localhost:8089"),
+		DonorChatIDs:          parseChatIDs(getEnv("DONOR_CHAT_IDS", "")),
+		AdminChatIDs:          parseChatIDs(getEnv("ADMIN_CHAT_IDS", "")),
+		DefaultLanguage:       strings.ToLower(getEnv("DEFAULT_LANGUAGE", "ru")),
+		AccessMode:            strings.ToLower(getEnv("ACCESS_MODE", "open")),
+		RequiredChannel:       strings.TrimSpace(getEnv("REQUIRED_CHANNEL", "")),
+		RequiredChannelLink:   getEnv("REQUIRED_CHANNEL_LINK", ""),
+		CookiesFile:           getEnv("COOKIES_FILE", ""),        // Deprecated: for backward compatibility
+		TikTokCookies:         getEnv("TIKTOK_COOKIES", ""),      // Path to TikTok cookies file
+		InstagramCookies:      getEnv("INSTAGRAM_COOKIES", ""),   // Path to Instagram cookies file
+		YouTubeCookies:        getEnv("YOUTUBE_COOKIES", ""),     // Path to YouTube cookies file
+		MinFreeMemMB:          getEnvInt("MIN_FREE_MEM_MB", 256), // Minimum free memory in MB
+		VoiceMaxSeconds:       getEnvInt("VOICE_MAX_SECONDS", 1200),
+		VideoNoteMaxSeconds:   getEnvInt("VIDEO_NOTE_MAX_SECONDS", 60), // Telegram limit is 60s
+		AnimationMaxSeconds:   getEnvInt("ANIMATION_MAX_SECONDS", 30),
+		AnimationMaxWidth:     getEnvInt("ANIMATION_MAX_WIDTH", 480),
+		SponsorBlockAPI:       getEnv("SPONSORBLOCK_API", ""),
+		LiveMaxMinutes:        getEnvInt("LIVE_MAX_MINUTES", 30),
+		LiveDonorMaxMinutes:   getEnvInt("LIVE_DONOR_MAX_MINUTES", 120),
+		LivePremiumMaxMinutes: getEnvInt("LIVE_PREMIUM_MAX_MINUTES", 360),
+		InlineChatID:          parseChatID(getEnv("INLINE_CHAT_ID", "")),
+		MaxLinksPerMessage:    getEnvInt("MAX_LINKS_PER_MESSAGE", 5),
+		PriorityPlans:         parsePriorityPlans(getEnv("PRIORITY_PLANS", "7:50,30:150,90:400")),
+
+		RateRequestsPerMinute:        getEnvInt("RATE_REQUESTS_PER_MINUTE", 10),
+		RateJobsPerDay:               getEnvInt("RATE_JOBS_PER_DAY", 100),
+		RateBytesPerDay:              int64(getEnvInt("RATE_MB_PER_DAY", 5120)) * 1024 * 1024,
+		RateDonorRequestsPerMinute:   getEnvInt("RATE_DONOR_REQUESTS_PER_MINUTE", 30),
+		RateDonorJobsPerDay:          getEnvInt("RATE_DONOR_JOBS_PER_DAY", 500),
+		RateDonorBytesPerDay:         int64(getEnvInt("RATE_DONOR_MB_PER_DAY", 20480)) * 1024 * 1024,
+		RatePremiumRequestsPerMinute: getEnvInt("RATE_PREMIUM_REQUESTS_PER_MINUTE", 60),
+		RatePremiumJobsPerDay:        getEnvInt("RATE_PREMIUM_JOBS_PER_DAY", 2000),
+		RatePremiumBytesPerDay:       int64(getEnvInt("RATE_PREMIUM_MB_PER_DAY", 102400)) * 1024 * 1024,
 	}
 
+	// Errors are shown in DEFAULT_LANGUAGE, or in Russian if it's invalid
+	lang := i18n.Match(cfg.DefaultLanguage, i18n.RU)
+
 	// Validate required fields
 	if cfg.BotToken == "" {
-		return nil, fmt.Errorf("BOT_TOKEN is required\n\n" +
-			"Пожалуйста, установите переменную окружения BOT_TOKEN:\n" +
-			"  export BOT_TOKEN=\"your_token_here\"\n\n" +
-			"Или создайте файл .env со следующим содержимым:\n" +
-			"  BOT_TOKEN=your_token_here\n\n" +
-			"Получить токен можно у @BotFather в Telegram:\n" +
-			"  1. Откройте https://t.me/BotFather\n" +
-			"  2. Отправьте команду /newbot\n" +
-			"  3. Следуйте инструкциям\n" +
-			"  4. Скопируйте полученный токен\n\n" +
-			"Подробнее см. QUICKSTART.md или README.md")
+		return nil, errors.New(i18n.T(lang, "config.bot_token"))
+	}
+
+	if !i18n.Supported(cfg.DefaultLanguage) {
+		return nil, errors.New(i18n.T(lang, "config.default_language", cfg.DefaultLanguage, strings.Join(i18n.Languages, ", ")))
+	}
+
+	switch cfg.AccessMode {
+	case "open", "allowlist", "denylist", "invite":
+	default:
+		return nil, errors.New(i18n.T(lang, "config.access_mode", cfg.AccessMode))
+	}
+
+	if channel := cfg.RequiredChannel; channel != "" {
+		byName := strings.HasPrefix(channel, "@")
+		if !byName && parseChatID(channel) == 0 {
+			return nil, errors.New(i18n.T(lang, "config.required_channel", channel))
+		}
+		if cfg.RequiredChannelLink == "" && !byName {
+			return nil, errors.New(i18n.T(lang, "config.required_channel_link", channel))
+		}
+		if cfg.RequiredChannelLink == "" {
+			cfg.RequiredChannelLink = "https://t.me/" + strings.TrimPrefix(channel, "@")
+		}
 	}
 
 	return cfg, nil
@@ -90,6 +168,11 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func parseChatID(s string) int64 {
+	id, _ := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return id
+}
+
 func parseChatIDs(s string) []int64 {
 	if s == "" {
 		return nil
@@ -105,3 +188,20 @@ func parseChatIDs(s string) []int64 {
 	}
 	return ids
 }
+
+// parsePriorityPlans parses "days:stars" pairs, e.g. "7:50,30:150"
+func parsePriorityPlans(s string) []PriorityPlan {
+	var plans []PriorityPlan
+	for _, part := range strings.Split(s, ",") {
+		days, stars, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			continue
+		}
+		d, err1 := strconv.Atoi(days)
+		st, err2 := strconv.Atoi(stars)
+		if err1 == nil && err2 == nil && d > 0 && st > 0 {
+			plans = append(plans, PriorityPlan{Days: d, Stars: st})
+		}
+	}
+	return plans
+}
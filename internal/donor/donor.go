@@ -0,0 +1,252 @@
+package donor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Donor tiers
+const (
+	TierBasic   = "basic"
+	TierPremium = "premium"
+)
+
+// ValidTier reports whether the tier is known
+func ValidTier(tier string) bool {
+	return tier == TierBasic || tier == TierPremium
+}
+
+// Donor is a chat with donor status
+type Donor struct {
+	ChatID    int64     `json:"chat_id"`
+	Tier      string    `json:"tier"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // Zero for permanent status
+	GrantedBy int64     `json:"granted_by,omitempty"` // Admin chat ID, 0 for purchases
+	GrantedAt time.Time `json:"granted_at"`
+	Static    bool      `json:"-"` // Listed in DONOR_CHAT_IDS, can't be revoked
+}
+
+// Permanent reports whether the status never expires
+func (d *Donor) Permanent() bool {
+	return d.ExpiresAt.IsZero()
+}
+
+// Store keeps donors in Redis, each under its own key that expires
+// together with the status. Chats from DONOR_CHAT_IDS are permanent donors.
+type Store struct {
+	client *redis.Client
+	ctx    context.Context
+	static []int64
+}
+
+// NewStore creates a donor store. client may be nil, then only the
+// static donors are known.
+func NewStore(client *redis.Client, static []int64) *Store {
+	return &Store{
+		client: client,
+		ctx:    context.Background(),
+		static: static,
+	}
+}
+
+func donorKey(chatID int64) string {
+	return fmt.Sprintf("donor:%d", chatID)
+}
+
+// IsDonor reports whether the chat currently has donor status
+func (s *Store) IsDonor(chatID int64) bool {
+	d, err := s.Get(chatID)
+	return err == nil && d != nil
+}
+
+// Tier returns the chat's donor tier, or "" if it has no donor status
+func (s *Store) Tier(chatID int64) string {
+	if d, err := s.Get(chatID); err == nil && d != nil {
+		return d.Tier
+	}
+	return ""
+}
+
+// Get returns the chat's donor status, or nil if it has none. Static
+// donors are permanent, a status granted on top of that can raise their tier.
+func (s *Store) Get(chatID int64) (*Donor, error) {
+	stored, err := s.stored(chatID)
+	if !s.isStatic(chatID) {
+		return stored, err
+	}
+	return withStatic(chatID, stored), nil
+}
+
+func (s *Store) isStatic(chatID int64) bool {
+	for _, id := range s.static {
+		if id == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+// withStatic merges a stored status into the permanent static one
+func withStatic(chatID int64, stored *Donor) *Donor {
+	d := &Donor{ChatID: chatID, Tier: TierBasic, Static: true}
+	if stored != nil {
+		d.Tier = higherTier(d.Tier, stored.Tier)
+		d.GrantedBy = stored.GrantedBy
+		d.GrantedAt = stored.GrantedAt
+	}
+	return d
+}
+
+// higherTier returns premium if either tier is premium
+func higherTier(a, b string) string {
+	if a == TierPremium || b == TierPremium {
+		return TierPremium
+	}
+	return TierBasic
+}
+
+// stored returns the status kept in Redis, ignoring DONOR_CHAT_IDS
+func (s *Store) stored(chatID int64) (*Donor, error) {
+	if s.client == nil {
+		return nil, nil
+	}
+
+	data, err := s.client.Get(s.ctx, donorKey(chatID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var d Donor
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal donor: %w", err)
+	}
+	return &d, nil
+}
+
+// Grant gives the chat donor status for the duration, 0 means forever.
+// An active status is extended rather than replaced.
+func (s *Store) Grant(chatID int64, tier string, duration time.Duration, grantedBy int64) (*Donor, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("donor store is not available")
+	}
+
+	now := time.Now()
+	d := &Donor{ChatID: chatID, Tier: tier, GrantedBy: grantedBy, GrantedAt: now}
+	if duration > 0 {
+		start := now
+		if current, err := s.stored(chatID); err == nil && current != nil {
+			if current.Permanent() {
+				duration = 0
+			} else if current.ExpiresAt.After(now) {
+				start = current.ExpiresAt
+			}
+		}
+		if duration > 0 {
+			d.ExpiresAt = start.Add(duration)
+		}
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal donor: %w", err)
+	}
+	var ttl time.Duration
+	if !d.Permanent() {
+		ttl = time.Until(d.ExpiresAt)
+	}
+	if err := s.client.Set(s.ctx, donorKey(chatID), data, ttl).Err(); err != nil {
+		return nil, err
+	}
+	if s.isStatic(chatID) {
+		return withStatic(chatID, d), nil
+	}
+	return d, nil
+}
+
+// Revoke removes the chat's donor status. Returns false if there was none.
+func (s *Store) Revoke(chatID int64) (bool, error) {
+	if s.client == nil {
+		return false, fmt.Errorf("donor store is not available")
+	}
+	n, err := s.client.Del(s.ctx, donorKey(chatID)).Result()
+	return n > 0, err
+}
+
+// Payment is a processed Stars purchase
+type Payment struct {
+	ChargeID string    `json:"charge_id"` // Telegram payment charge ID, needed for refunds
+	ChatID   int64     `json:"chat_id"`
+	Stars    int       `json:"stars"`
+	Days     int       `json:"days"`
+	PaidAt   time.Time `json:"paid_at"`
+}
+
+func paymentKey(chargeID string) string {
+	return fmt.Sprintf("payment:%s", chargeID)
+}
+
+// RecordPayment stores the payment unless its charge ID was recorded
+// before. Returns false for payments that were already processed, e.g.
+// when Telegram delivers the update again.
+func (s *Store) RecordPayment(p Payment) (bool, error) {
+	if s.client == nil {
+		return false, fmt.Errorf("donor store is not available")
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal payment: %w", err)
+	}
+	return s.client.SetNX(s.ctx, paymentKey(p.ChargeID), data, 0).Result()
+}
+
+// ForgetPayment removes the payment record so the payment can be processed again
+func (s *Store) ForgetPayment(chargeID string) error {
+	if s.client == nil {
+		return fmt.Errorf("donor store is not available")
+	}
+	return s.client.Del(s.ctx, paymentKey(chargeID)).Err()
+}
+
+// List returns all donors, static ones first, then by expiry
+func (s *Store) List() ([]*Donor, error) {
+	var donors []*Donor
+	for _, id := range s.static {
+		stored, _ := s.stored(id)
+		donors = append(donors, withStatic(id, stored))
+	}
+	if s.client == nil {
+		return donors, nil
+	}
+
+	var stored []*Donor
+	iter := s.client.Scan(s.ctx, 0, "donor:*", 100).Iterator()
+	for iter.Next(s.ctx) {
+		id, err := strconv.ParseInt(strings.TrimPrefix(iter.Val(), "donor:"), 10, 64)
+		if err != nil {
+			continue
+		}
+		if d, err := s.Get(id); err == nil && d != nil && !d.Static {
+			stored = append(stored, d)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return donors, err
+	}
+
+	sort.Slice(stored, func(i, j int) bool {
+		if stored[i].Permanent() != stored[j].Permanent() {
+			return stored[i].Permanent()
+		}
+		return stored[i].ExpiresAt.Before(stored[j].ExpiresAt)
+	})
+	return append(donors, stored...), nil
+}
@@ -0,0 +1,66 @@
+package executor
+
+import "strings"
+
+// audioFormat describes an audio output format users can choose
+type audioFormat struct {
+	codec    string // yt-dlp --audio-format value
+	ext      string // Resulting file extension
+	selector string // yt-dlp format selector preferring sources already in this codec
+	lossless bool   // Bitrate setting doesn't apply
+}
+
+var audioFormats = map[string]audioFormat{
+	"mp3":  {codec: "mp3", ext: "mp3", selector: "bestaudio[acodec=mp3]/bestaudio/best"},
+	"m4a":  {codec: "m4a", ext: "m4a", selector: "bestaudio[ext=m4a]/bestaudio/best"},
+	"opus": {codec: "opus", ext: "opus", selector: "bestaudio[acodec=opus]/bestaudio/best"},
+	"ogg":  {codec: "vorbis", ext: "ogg", selector: "bestaudio[acodec=vorbis]/bestaudio/best"},
+	"flac": {codec: "flac", ext: "flac", selector: "bestaudio/best", lossless: true},
+}
+
+// AudioFormats lists the supported audio output formats in display order
+var AudioFormats = []string{"mp3", "m4a", "opus", "ogg", "flac"}
+
+// AudioBitrates lists the supported bitrates in kbit/s, "best" keeps the source quality
+var AudioBitrates = []string{"best", "320", "256", "192", "128", "96"}
+
+// IsAudioFormat reports whether the format is supported
+func IsAudioFormat(format string) bool {
+	_, ok := audioFormats[format]
+	return ok
+}
+
+// IsAudioBitrate reports whether the bitrate is supported
+func IsAudioBitrate(bitrate string) bool {
+	for _, b := range AudioBitrates {
+		if b == bitrate {
+			return true
+		}
+	}
+	return false
+}
+
+// getAudioFormat returns the format, falling back to MP3 for unknown values
+func getAudioFormat(format string) audioFormat {
+	if f, ok := audioFormats[strings.ToLower(format)]; ok {
+		return f
+	}
+	return audioFormats["mp3"]
+}
+
+// audioArgs returns yt-dlp arguments to extract audio in the requested
+// format. The format selector prefers streams already in the target codec,
+// so yt-dlp can copy the stream instead of re-encoding it.
+func audioArgs(format, bitrate string) []string {
+	f := getAudioFormat(format)
+
+	quality := "0" // Best VBR quality
+	if !f.lossless && bitrate != "" && bitrate != "best" {
+		quality = bitrate + "K"
+	}
+
+	return []string{
+		"--format", f.selector,
+		"-x", "--audio-format", f.codec, "--audio-quality", quality,
+	}
+}
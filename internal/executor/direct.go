@@ -0,0 +1,405 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"envedour-bot/internal/config"
+	"envedour-bot/internal/extractor"
+	"envedour-bot/internal/queue"
+)
+
+const (
+	directPartCount   = 8
+	directMinPartSize = 4 * 1024 * 1024 // Smaller files are fetched in one request
+	directMaxRetries  = 3
+)
+
+// directDownloader fetches plain media files (.mp4, .mp3, .webm) over HTTP
+// without going through yt-dlp. Large files are split into byte ranges
+// downloaded in parallel; interrupted ranges resume from the last byte written.
+type directDownloader struct {
+	config *config.Config
+	client *http.Client
+}
+
+func newDirectDownloader(cfg *config.Config) *directDownloader {
+	return &directDownloader{
+		config: cfg,
+		client: &http.Client{Timeout: 0}, // Downloads are bounded by ctx instead
+	}
+}
+
+// Match accepts direct media links unless audio extraction from a video file
+// or an audio conversion was requested, which still need yt-dlp and ffmpeg
+func (d *directDownloader) Match(job *queue.Job) bool {
+	switch extractor.DirectMediaType(job.URL) {
+	case "video":
+		return job.MediaType != "audio"
+	case "audio":
+		return audioMatches(job)
+	}
+	return false
+}
+
+// audioMatches reports whether a direct audio file is already in the format
+// and bitrate of the job and can be sent as is
+func audioMatches(job *queue.Job) bool {
+	u, err := url.Parse(job.URL)
+	if err != nil {
+		return false
+	}
+	f := getAudioFormat(job.AudioFormat)
+	if strings.TrimPrefix(strings.ToLower(path.Ext(u.Path)), ".") != f.ext {
+		return false
+	}
+	return f.lossless || job.AudioBitrate == "" || job.AudioBitrate == "best"
+}
+
+func (d *directDownloader) Download(ctx context.Context, job *queue.Job) (*Media, error) {
+	size, ranges, err := d.head(ctx, job.URL)
+	if err != nil {
+		return nil, err
+	}
+	if size > d.config.MaxFileSize {
+		return nil, fmt.Errorf("file too large: %d bytes (max: %d)", size, d.config.MaxFileSize)
+	}
+
+	outputPath := d.filePath(job)
+	// Audio keeps its name in a directory of the job, removed along with the file
+	jobDir := ""
+	if dir := filepath.Dir(outputPath); dir != filepath.Clean(d.config.TmpfsPath) {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+		jobDir = dir
+	}
+	removeOutput := func() {
+		os.Remove(outputPath)
+		if jobDir != "" {
+			os.Remove(jobDir)
+		}
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		removeOutput()
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	progress := newProgressReporter(job.ID, size)
+	if ranges && size >= directMinPartSize {
+		err = d.downloadParallel(ctx, job.URL, file, size, progress)
+	} else {
+		err = d.downloadRange(ctx, job.URL, file, 0, -1, progress)
+	}
+	file.Close()
+	if err != nil {
+		removeOutput()
+		return nil, err
+	}
+
+	if err := checkMediaContent(outputPath); err != nil {
+		removeOutput()
+		return nil, err
+	}
+
+	// Files are fetched whole, cut the requested fragment afterwards
+	if job.IsClipped() {
+		if err := trimMedia(ctx, outputPath, job.ClipStart, job.ClipEnd); err != nil {
+			removeOutput()
+			return nil, err
+		}
+	}
+
+	media := &Media{Path: outputPath}
+	if jobDir != "" {
+		media.addTempFile(jobDir) // Empty once the file is removed
+	}
+	if info, err := probeMedia(ctx, outputPath); err == nil {
+		media.Duration = info.duration()
+		// Files carry their own tags, pass them on to Telegram
+		if job.EmbedTags {
+			media.Title = info.tag("title")
+			media.Performer = info.tag("artist")
+			media.Album = info.tag("album")
+		}
+	}
+
+	return media, nil
+}
+
+// head returns the file size (-1 if unknown) and whether byte ranges are supported
+func (d *directDownloader) head(ctx context.Context, rawURL string) (int64, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, false, fmt.Errorf("HEAD request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	// Some servers (e.g. signed storage links) only allow GET
+	if resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusForbidden {
+		return d.probeRange(ctx, rawURL)
+	}
+	if resp.StatusCode >= 400 {
+		return 0, false, fmt.Errorf("HEAD request failed: %s", resp.Status)
+	}
+	if err := checkContentType(resp); err != nil {
+		return 0, false, err
+	}
+
+	return resp.ContentLength, resp.Header.Get("Accept-Ranges") == "bytes", nil
+}
+
+// probeRange does what head does with a GET of the first byte. The total
+// size comes from Content-Range; a server ignoring the range answers with
+// the whole file, which is closed right away.
+func (d *directDownloader) probeRange(ctx context.Context, rawURL string) (int64, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid URL: %w", err)
+	}
+	req.Header.Set("Range", "bytes=0-0")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, false, fmt.Errorf("GET request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return 0, false, fmt.Errorf("GET request failed: %s", resp.Status)
+	}
+	if err := checkContentType(resp); err != nil {
+		return 0, false, err
+	}
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return resp.ContentLength, false, nil
+	}
+	// Content-Range: bytes 0-0/12345, the total is "*" if unknown
+	contentRange := resp.Header.Get("Content-Range")
+	total := contentRange[strings.LastIndex(contentRange, "/")+1:]
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return -1, true, nil
+	}
+	return size, true, nil
+}
+
+// checkContentType rejects responses that are obviously not media, like HTML pages
+func checkContentType(resp *http.Response) error {
+	if contentType := resp.Header.Get("Content-Type"); strings.HasPrefix(contentType, "text/") {
+		return fmt.Errorf("not a media file: %s", contentType)
+	}
+	return nil
+}
+
+// downloadParallel splits the file into ranges and fetches them concurrently
+func (d *directDownloader) downloadParallel(ctx context.Context, rawURL string, file *os.File, size int64, progress *progressReporter) error {
+	if err := file.Truncate(size); err != nil {
+		return fmt.Errorf("failed to allocate file: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	partSize := size / directPartCount
+	var wg sync.WaitGroup
+	errs := make(chan error, directPartCount)
+
+	for i := int64(0); i < directPartCount; i++ {
+		start := i * partSize
+		end := start + partSize - 1
+		if i == directPartCount-1 {
+			end = size - 1
+		}
+
+		wg.Add(1)
+		go func(start, end int64) {
+			defer wg.Done()
+			if err := d.downloadRange(ctx, rawURL, file, start, end, progress); err != nil {
+				errs <- err
+				cancel()
+			}
+		}(start, end)
+	}
+
+	wg.Wait()
+	close(errs)
+	return <-errs // nil if channel is empty
+}
+
+// downloadRange writes bytes [start, end] of the file at the same offsets.
+// end < 0 means "until EOF" and disables range requests.
+// On a broken connection the request is retried from the last written byte.
+func (d *directDownloader) downloadRange(ctx context.Context, rawURL string, file *os.File, start, end int64, progress *progressReporter) error {
+	offset := start
+	var lastErr error
+
+	for attempt := 0; attempt < directMaxRetries; attempt++ {
+		if attempt > 0 {
+			if end < 0 {
+				// Without ranges there is nothing to resume, start over
+				offset = start
+				progress.reset()
+			}
+			log.Printf("Direct download of %s: retrying from byte %d (%v)", rawURL, offset, lastErr)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return fmt.Errorf("invalid URL: %w", err)
+		}
+		if end >= 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, end))
+		}
+
+		resp, err := d.client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+
+		if (end >= 0 && resp.StatusCode != http.StatusPartialContent) || (end < 0 && resp.StatusCode != http.StatusOK) {
+			resp.Body.Close()
+			return fmt.Errorf("GET request failed: %s", resp.Status)
+		}
+
+		written, err := d.copyAt(file, resp.Body, offset, progress)
+		resp.Body.Close()
+		offset += written
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		lastErr = err
+	}
+
+	return fmt.Errorf("download failed after %d attempts: %w", directMaxRetries, lastErr)
+}
+
+// copyAt copies the body into the file starting at offset and stops if
+// the file grows past MaxFileSize (servers may omit Content-Length)
+func (d *directDownloader) copyAt(file *os.File, body io.Reader, offset int64, progress *progressReporter) (int64, error) {
+	buf := make([]byte, 256*1024)
+	var written int64
+
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if offset+written+int64(n) > d.config.MaxFileSize {
+				return written, fmt.Errorf("file too large (max: %d bytes)", d.config.MaxFileSize)
+			}
+			if _, werr := file.WriteAt(buf[:n], offset+written); werr != nil {
+				return written, fmt.Errorf("failed to write file: %w", werr)
+			}
+			written += int64(n)
+			progress.add(int64(n))
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// filePath keeps the original name for audio (it becomes the track title
+// in Telegram) under a directory named after the job, so jobs with the
+// same file name don't collide. Video is saved under the job ID, like
+// the yt-dlp downloader does.
+func (d *directDownloader) filePath(job *queue.Job) string {
+	u, _ := url.Parse(job.URL)
+	base := path.Base(u.Path)
+	ext := strings.ToLower(path.Ext(base))
+
+	if extractor.DirectMediaType(job.URL) == "audio" {
+		name := strings.TrimSuffix(base, path.Ext(base))
+		if decoded, err := url.PathUnescape(name); err == nil {
+			name = decoded
+		}
+		if name = sanitizeFilename(name); name != "" {
+			return filepath.Join(d.config.TmpfsPath, job.ID, name+ext)
+		}
+	}
+	return filepath.Join(d.config.TmpfsPath, job.ID+ext)
+}
+
+// checkMediaContent sniffs the first bytes of the file to make sure the
+// server didn't hand us an HTML error page with a 200 status
+func checkMediaContent(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header := make([]byte, 512)
+	n, _ := file.Read(header)
+	contentType := http.DetectContentType(header[:n])
+
+	switch {
+	case strings.HasPrefix(contentType, "video/"),
+		strings.HasPrefix(contentType, "audio/"),
+		contentType == "application/octet-stream": // MP4 variants DetectContentType doesn't know
+		return nil
+	}
+	return fmt.Errorf("downloaded file is not media: %s", contentType)
+}
+
+// progressReporter logs download progress every 10%
+type progressReporter struct {
+	jobID    string
+	total    int64
+	done     int64
+	reported int64
+	started  time.Time
+}
+
+func newProgressReporter(jobID string, total int64) *progressReporter {
+	return &progressReporter{jobID: jobID, total: total, started: time.Now()}
+}
+
+func (p *progressReporter) add(n int64) {
+	done := atomic.AddInt64(&p.done, n)
+	if p.total <= 0 {
+		return
+	}
+
+	percent := done * 100 / p.total
+	step := percent / 10 * 10
+	if reported := atomic.LoadInt64(&p.reported); step > reported && atomic.CompareAndSwapInt64(&p.reported, reported, step) {
+		elapsed := time.Since(p.started).Seconds()
+		speed := float64(done) / 1024 / 1024 / elapsed
+		log.Printf("Job %s: downloaded %d%% (%.1f MB/s)", p.jobID, step, speed)
+	}
+}
+
+func (p *progressReporter) reset() {
+	atomic.StoreInt64(&p.done, 0)
+	atomic.StoreInt64(&p.reported, 0)
+}
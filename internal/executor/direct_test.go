@@ -0,0 +1,28 @@
+package executor
+
+import (
+	"testing"
+
+	"envedour-bot/internal/queue"
+)
+
+func TestDirectMatch(t *testing.T) {
+	for _, tc := range []struct {
+		url, mediaType, format, bitrate string
+		want                            bool
+	}{
+		{"https://example.com/clip.mp4", "video", "", "", true},
+		{"https://example.com/clip.mp4", "audio", "mp3", "best", false},
+		{"https://example.com/song.mp3", "audio", "mp3", "best", true},
+		{"https://example.com/song.MP3", "audio", "mp3", "", true},
+		{"https://example.com/song.mp3", "audio", "opus", "best", false},
+		{"https://example.com/song.mp3", "audio", "mp3", "128", false},
+		{"https://example.com/clip.webm", "video", "mp3", "128", true},
+		{"https://example.com/page.html", "video", "", "", false},
+	} {
+		job := &queue.Job{URL: tc.url, MediaType: tc.mediaType, AudioFormat: tc.format, AudioBitrate: tc.bitrate}
+		if got := newDirectDownloader(nil).Match(job); got != tc.want {
+			t.Errorf("Match(%s, %s %s/%s) = %v, want %v", tc.url, tc.mediaType, tc.format, tc.bitrate, got, tc.want)
+		}
+	}
+}
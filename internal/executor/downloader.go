@@ -0,0 +1,66 @@
+package executor
+
+import (
+	"context"
+	"os"
+
+	"envedour-bot/internal/queue"
+)
+
+// Downloader fetches the media behind a job into the tmpfs directory
+type Downloader interface {
+	// Match reports whether the downloader can handle the job
+	Match(job *queue.Job) bool
+	Download(ctx context.Context, job *queue.Job) (*Media, error)
+}
+
+// Media is a downloaded file together with what is known about it
+type Media struct {
+	Path      string
+	Title     string
+	Performer string
+	Album     string
+	Duration  float64  // Seconds, 0 if unknown
+	ThumbPath string   // JPEG thumbnail for Telegram, empty if unavailable
+	SubsPath  string   // SRT subtitles, empty if not requested or not found
+	Removed   float64  // Seconds cut out by SponsorBlock
+	Caption   string   // Caption sent along with the file
+	Chapters  []*Media // One file per chapter when splitting was requested
+
+	tempFiles []string          // Removed together with the media file
+	sent      *queue.CachedFile // Set once the file is uploaded to Telegram
+	sentMsgID int               // Message the file was uploaded with
+}
+
+// addTempFile registers an extra file to remove in Cleanup
+func (m *Media) addTempFile(path string) {
+	m.tempFiles = append(m.tempFiles, path)
+}
+
+// Cleanup removes the media file and all files produced along with it
+func (m *Media) Cleanup() {
+	os.Remove(m.Path)
+	if m.ThumbPath != "" {
+		os.Remove(m.ThumbPath)
+	}
+	if m.SubsPath != "" {
+		os.Remove(m.SubsPath)
+	}
+	// Newest first, so directories registered early are empty by then
+	for i := len(m.tempFiles) - 1; i >= 0; i-- {
+		os.Remove(m.tempFiles[i])
+	}
+	for _, chapter := range m.Chapters {
+		chapter.Cleanup()
+	}
+}
+
+// downloaderFor returns the first registered downloader matching the job
+func (e *Executor) downloaderFor(job *queue.Job) Downloader {
+	for _, d := range e.downloaders {
+		if d.Match(job) {
+			return d
+		}
+	}
+	return nil
+}
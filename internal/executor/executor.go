@@ -2,19 +2,24 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"envedour-bot/internal/config"
+	"envedour-bot/internal/history"
+	"envedour-bot/internal/i18n"
 	"envedour-bot/internal/queue"
+	"envedour-bot/internal/ratelimit"
 
+	"github.com/go-redis/redis/v8"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
@@ -32,9 +37,14 @@ type Executor struct {
 	armOptimized bool
 	botAPI       *tgbotapi.BotAPI
 	thermalMon   ThermalMonitor
+	downloaders  []Downloader
+	history      *history.Store // nil without Redis
+
+	workersMu sync.Mutex
+	workers   []WorkerState
 }
 
-func NewExecutor(cfg *config.Config, armOptimized bool) *Executor {
+func NewExecutor(cfg *config.Config, client *redis.Client, armOptimized bool) *Executor {
 	// Initialize bot API for sending files
 	botAPI, _ := tgbotapi.NewBotAPI(cfg.BotToken)
 	if botAPI != nil && cfg.LocalAPIURL != "" {
@@ -53,6 +63,13 @@ func NewExecutor(cfg *config.Config, armOptimized bool) *Executor {
 		config:       cfg,
 		armOptimized: armOptimized,
 		botAPI:       botAPI,
+		downloaders: []Downloader{
+			newDirectDownloader(cfg),
+			newYtdlpDownloader(cfg, armOptimized), // Fallback, must stay last
+		},
+	}
+	if client != nil {
+		exec.history = history.New(client)
 	}
 
 	// Initialize thermal monitor on ARM64 if requested
@@ -72,6 +89,7 @@ func NewExecutor(cfg *config.Config, armOptimized bool) *Executor {
 }
 
 func (e *Executor) Worker(ctx context.Context, q queue.Queue) {
+	id := e.addWorker()
 	for {
 		select {
 		case <-ctx.Done():
@@ -88,16 +106,18 @@ func (e *Executor) Worker(ctx context.Context, q queue.Queue) {
 				continue
 			}
 
-			e.processJob(ctx, job)
+			e.setWorkerJob(id, job)
+			e.processJob(ctx, q, job)
+			e.setWorkerJob(id, nil)
 		}
 	}
 }
 
-func (e *Executor) processJob(ctx context.Context, job *queue.Job) {
+func (e *Executor) processJob(ctx context.Context, q queue.Queue, job *queue.Job) {
 	// Check thermal throttling if ARM optimized
 	if e.armOptimized && e.thermalMon != nil {
 		if e.thermalMon.IsThrottled() {
-			e.sendMessage(job.ChatID, "Система перегружена (высокая температура). Попробуйте позже.")
+			e.notify(job, i18n.T(job.Lang, "exec.overheated"))
 			time.Sleep(5 * time.Second)
 			return
 		}
@@ -105,374 +125,240 @@ func (e *Executor) processJob(ctx context.Context, job *queue.Job) {
 
 	// Check available memory
 	if err := e.checkMemory(); err != nil {
-		e.sendMessage(job.ChatID, "Недостаточно памяти на устройстве. Попробуйте позже.")
+		e.notify(job, i18n.T(job.Lang, "exec.low_memory"))
 		return
 	}
 
 	// Set default values if not set
-	quality := job.Quality
-	if quality == "" {
-		quality = "best"
+	if job.Quality == "" {
+		job.Quality = "best"
+	}
+	if job.MediaType == "" {
+		job.MediaType = "video"
 	}
 	mediaType := job.MediaType
-	if mediaType == "" {
-		mediaType = "video"
+	log.Printf("Job %s: %s (%s, %s)", job.ID, job.URL, mediaType, job.Quality)
+
+	// Inline results that were uploaded meanwhile don't need another download
+	if job.InlineMessage != "" && e.deliverCachedInline(q, job) {
+		return
 	}
 
 	// Download media (video or audio)
-	filePath, err := e.downloadMedia(ctx, job.URL, job.ID, quality, mediaType)
+	media, err := e.downloaderFor(job).Download(ctx, job)
+	if errors.Is(err, errLiveStream) {
+		if job.InlineMessage != "" {
+			e.notify(job, i18n.T(job.Lang, "live.inline_unavailable"))
+			return
+		}
+		e.offerRecording(q, job)
+		return
+	}
 	if err != nil {
 		log.Printf("Download error: %v", err)
-		userMsg := "❌ Ошибка при скачивании.\n\nВозможные причины:\n• Неверная ссылка\n• Видео недоступно\n• Проблемы с сетью\n• Недостаточно памяти\n\nПопробуйте другую ссылку или повторите позже."
-		e.sendMessage(job.ChatID, userMsg)
+		e.notify(job, i18n.T(job.Lang, "exec.download_failed"))
 		return
 	}
-	defer os.Remove(filePath)
+	defer media.Cleanup()
 
-	// Send media based on type
-	if mediaType == "audio" {
-		if err := e.sendAudio(job.ChatID, filePath); err != nil {
+	if media.Removed >= 1 {
+		media.Caption = i18n.T(job.Lang, "exec.sponsorblock_cut", formatDuration(job.Lang, media.Removed))
+	}
+
+	if job.SubMode != "" && mediaType == "video" {
+		e.prepareSubtitles(ctx, job, media)
+	}
+
+	// Send media based on delivery mode and type
+	switch {
+	case job.Delivery == "voice":
+		if err := e.sendVoice(ctx, job, media); err != nil {
+			log.Printf("Voice send error: %v", err)
+			e.notify(job, deliveryErrorMessage(job.Lang, err, i18n.T(job.Lang, "exec.what_voice")))
+			return
+		}
+	case job.Delivery == "video_note":
+		if err := e.sendVideoNote(ctx, job, media); err != nil {
+			log.Printf("Video note send error: %v", err)
+			e.notify(job, deliveryErrorMessage(job.Lang, err, i18n.T(job.Lang, "exec.what_video_note")))
+			return
+		}
+	case mediaType == "animation":
+		if err := e.sendAnimation(ctx, job, media); err != nil {
+			log.Printf("Animation send error: %v", err)
+			e.notify(job, i18n.T(job.Lang, "exec.gif_failed"))
+			return
+		}
+	case mediaType == "audio" && len(media.Chapters) > 0:
+		if err := e.sendAudioChapters(job, media); err != nil {
+			log.Printf("Chapters send error: %v", err)
+			e.notify(job, i18n.T(job.Lang, "exec.chapters_failed"))
+			return
+		}
+	case mediaType == "audio":
+		if job.Chapters {
+			e.notify(job, i18n.T(job.Lang, "exec.no_chapters"))
+		}
+		if err := e.sendAudio(job, media); err != nil {
 			log.Printf("Audio send error: %v", err)
-			userMsg := "❌ Ошибка при отправке аудио.\n\nВозможно, файл слишком большой или поврежден.\nПопробуйте другую ссылку."
-			e.sendMessage(job.ChatID, userMsg)
+			e.notify(job, i18n.T(job.Lang, "exec.audio_failed"))
 			return
 		}
-	} else {
-		if err := e.sendVideo(job.ChatID, filePath); err != nil {
+	default:
+		if err := e.sendVideo(job, media); err != nil {
 			log.Printf("Video send error: %v", err)
-			userMsg := "❌ Ошибка при отправке видео.\n\n"
+			userMsg := i18n.T(job.Lang, "exec.video_failed") + "\n\n"
 			if err.Error() == "bot API not initialized" {
-				userMsg += "Проблема с конфигурацией бота. Обратитесь к администратору."
-			} else if filepath.Ext(filePath) != "" {
-				userMsg += "Возможно, файл слишком большой или поврежден.\nПопробуйте другую ссылку."
+				userMsg += i18n.T(job.Lang, "exec.video_failed_config")
+			} else if filepath.Ext(media.Path) != "" {
+				userMsg += i18n.T(job.Lang, "exec.video_failed_file")
 			} else {
-				userMsg += "Попробуйте повторить запрос позже."
+				userMsg += i18n.T(job.Lang, "exec.video_failed_retry")
 			}
-			e.sendMessage(job.ChatID, userMsg)
+			e.notify(job, userMsg)
 			return
 		}
-	}
-}
-
-func (e *Executor) downloadMedia(ctx context.Context, url, jobID, quality, mediaType string) (string, error) {
-	var outputPath string
-	var title string
-	var tempCookiesFiles []string // Track temp cookies files for cleanup
-
-	// Helper function to create temp cookies file
-	createTempCookies := func(originalFile string) string {
-		tempCookiesFile := filepath.Join(e.config.TmpfsPath, fmt.Sprintf("cookies_%s_%d.txt", jobID, time.Now().UnixNano()))
-		if data, err := os.ReadFile(originalFile); err == nil {
-			if err := os.WriteFile(tempCookiesFile, data, 0644); err == nil {
-				os.Chmod(tempCookiesFile, 0644)
-				tempCookiesFiles = append(tempCookiesFiles, tempCookiesFile)
-				return tempCookiesFile
+		if job.SubMode == "file" && media.SubsPath != "" {
+			if err := e.sendDocument(job, media.SubsPath); err != nil {
+				log.Printf("Subtitles send error: %v", err)
 			}
 		}
-		return originalFile // Fallback to original
 	}
 
-	// Cleanup temp cookies files at the end
-	defer func() {
-		for _, tempFile := range tempCookiesFiles {
-			os.Remove(tempFile)
-		}
-	}()
-
-	// For audio, get title first to use in filename (to avoid truncation)
-	if mediaType == "audio" {
-		// Get video title using yt-dlp --print (before downloading)
-		titleArgs := []string{"--no-cache-dir", "--print", "%(title)s", url}
-		if cookiesFile := e.getCookiesFile(url); cookiesFile != "" {
-			tempCookies := createTempCookies(cookiesFile)
-			titleArgs = append(titleArgs, "--cookies", tempCookies)
-		}
-		// Add TikTok-specific options for title extraction
-		if strings.Contains(url, "tiktok.com") || strings.Contains(url, "vt.tiktok.com") {
-			titleArgs = append(titleArgs,
-				"--no-check-certificate",
-				"-4", // Force IPv4
-				"--legacy-server-connect",
-				"--user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
-				"--referer", "https://www.tiktok.com/",
-				"--add-header", "Accept:text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8",
-				"--add-header", "Accept-Language:en-US,en;q=0.9",
-				"--add-header", "Accept-Encoding:gzip, deflate, br",
-				"--add-header", "DNT:1",
-				"--add-header", "Connection:keep-alive",
-				"--add-header", "Upgrade-Insecure-Requests:1",
-				"--add-header", "Sec-Fetch-Dest:document",
-				"--add-header", "Sec-Fetch-Mode:navigate",
-				"--add-header", "Sec-Fetch-Site:none",
-				"--add-header", "Sec-Fetch-User:?1",
-			)
-		}
-		
-		titleCmd := exec.CommandContext(ctx, "yt-dlp", titleArgs...)
-		titleBytes, err := titleCmd.Output()
-		if err == nil && len(titleBytes) > 0 {
-			title = strings.TrimSpace(string(titleBytes))
-			// Sanitize title for filesystem
-			title = sanitizeFilename(title)
-			// Limit to reasonable length (220 chars) to avoid filesystem limits
-			if len(title) > 220 {
-				title = title[:220]
-			}
-		}
-		// If title extraction failed or empty, use timestamp-based name
-		if title == "" {
-			title = fmt.Sprintf("audio_%d", time.Now().Unix())
-		}
-		// Use only title in output path (no jobID prefix)
-		outputPath = filepath.Join(e.config.TmpfsPath, fmt.Sprintf("%s.%%(ext)s", title))
+	e.cacheFile(q, job, media)
+	e.recordTraffic(q, job, media)
+	if job.InlineMessage != "" {
+		e.deliverInline(job, media)
 	} else {
-		outputPath = filepath.Join(e.config.TmpfsPath, fmt.Sprintf("%s.%%(ext)s", jobID))
-	}
-
-	// Build yt-dlp command arguments
-	args := []string{
-		"--no-cache-dir",
-		"--external-downloader", "aria2c",
-		"--external-downloader-args", "aria2c:-j16 -x16 -s16 --file-allocation=falloc --stream-piece-selector=geom --max-download-limit=0",
-		"-o", outputPath,
-		// Disable automatic cookie extraction from browsers (server doesn't have browsers)
-		"--no-cookies-from-browser",
-	}
-
-	// Add TikTok-specific options to bypass restrictions
-	if strings.Contains(url, "tiktok.com") || strings.Contains(url, "vt.tiktok.com") {
-		// TikTok requires specific headers and settings
-		args = append(args,
-			"--no-check-certificate",
-			"-4", // Force IPv4
-			"--legacy-server-connect",
-			"--user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
-			"--referer", "https://www.tiktok.com/",
-			"--add-header", "Accept:text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8",
-			"--add-header", "Accept-Language:en-US,en;q=0.9",
-			"--add-header", "Accept-Encoding:gzip, deflate, br",
-			"--add-header", "DNT:1",
-			"--add-header", "Connection:keep-alive",
-			"--add-header", "Upgrade-Insecure-Requests:1",
-			"--add-header", "Sec-Fetch-Dest:document",
-			"--add-header", "Sec-Fetch-Mode:navigate",
-			"--add-header", "Sec-Fetch-Site:none",
-			"--add-header", "Sec-Fetch-User:?1",
-			"--add-header", "sec-ch-ua:\"Google Chrome\";v=\"131\", \"Chromium\";v=\"131\", \"Not_A Brand\";v=\"24\"",
-			"--add-header", "sec-ch-ua-mobile:?0",
-			"--add-header", "sec-ch-ua-platform:\"Windows\"",
-		)
-	} else {
-		// For other sites, use standard SSL settings
-		args = append(args, "--legacy-server-connect")
+		e.recordHistory(job, media)
 	}
+}
 
-	// Set format based on media type and quality
-	if mediaType == "audio" {
-		args = append(args, "-x", "--audio-format", "mp3", "--audio-quality", "0")
-	} else {
-		format := e.getFormatForQuality(quality)
-		args = append(args, "--format", format)
+// prepareSubtitles burns subtitles into the video if requested and tells
+// the user when subtitles in the requested language don't exist
+func (e *Executor) prepareSubtitles(ctx context.Context, job *queue.Job, media *Media) {
+	if media.SubsPath == "" {
+		e.notify(job, i18n.T(job.Lang, "exec.subs_not_found", job.SubLang))
+		return
+	}
+	if job.SubMode != "burn" {
+		return
 	}
 
-	// Add cookies file if configured (platform-specific or fallback)
-	// Use temporary copy for cookies to avoid permission issues when yt-dlp tries to save them
-	if cookiesFile := e.getCookiesFile(url); cookiesFile != "" {
-		tempCookies := createTempCookies(cookiesFile)
-		args = append(args, "--cookies", tempCookies)
-	} else if strings.Contains(url, "tiktok.com") || strings.Contains(url, "vt.tiktok.com") {
-		log.Printf("Warning: TikTok URL detected but no cookies file configured. TikTok may require cookies to bypass 403 errors.")
+	burned, err := burnSubtitles(ctx, media.Path, media.SubsPath)
+	if err != nil {
+		log.Printf("Subtitles burn-in error: %v", err)
+		e.notify(job, i18n.T(job.Lang, "exec.subs_burn_failed"))
+		return
 	}
+	media.addTempFile(media.Path)
+	media.Path = burned
+}
 
-	// Add URL at the end
-	args = append(args, url)
+func (e *Executor) sendAudio(job *queue.Job, media *Media) error {
+	if e.botAPI == nil {
+		return fmt.Errorf("bot API not initialized")
+	}
 
-	// Use yt-dlp to get video info and download
-	ytdlpCmd := exec.CommandContext(ctx, "yt-dlp", args...)
+	file, err := os.Open(media.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open audio file: %w", err)
+	}
+	defer file.Close()
 
-	ytdlpCmd.Env = os.Environ()
-	if e.armOptimized && runtime.GOARCH == "arm64" {
-		// Set ARM-specific environment
-		ytdlpCmd.Env = append(ytdlpCmd.Env, "FFMPEG_BINARY=ffmpeg")
+	stat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat audio file: %w", err)
 	}
 
-	output, err := ytdlpCmd.CombinedOutput()
-	// Clean up temp cookies files even if download fails
-	for _, tempFile := range tempCookiesFiles {
-		os.Remove(tempFile)
+	// Check file size
+	if stat.Size() > e.config.MaxFileSize {
+		return fmt.Errorf("file too large: %d bytes (max: %d)", stat.Size(), e.config.MaxFileSize)
 	}
-	if err != nil {
-		return "", fmt.Errorf("yt-dlp failed: %w\nOutput: %s", err, output)
-	}
-
-	// Find the downloaded file
-	var matches []string
-	if mediaType == "audio" {
-		// For audio, look for MP3 file with title name
-		if title != "" && !strings.HasPrefix(title, "audio_") {
-			// Try exact match first
-			pattern := filepath.Join(e.config.TmpfsPath, title+".mp3")
-			if _, err := os.Stat(pattern); err == nil {
-				matches = []string{pattern}
-			}
-		}
-		// If not found, find most recently created MP3 file
-		if len(matches) == 0 {
-			pattern := filepath.Join(e.config.TmpfsPath, "*.mp3")
-			allMatches, _ := filepath.Glob(pattern)
-			if len(allMatches) > 0 {
-				// Sort by modification time, get most recent
-				var mostRecent string
-				var mostRecentTime time.Time
-				for _, match := range allMatches {
-					if stat, err := os.Stat(match); err == nil {
-						if stat.ModTime().After(mostRecentTime) {
-							mostRecentTime = stat.ModTime()
-							mostRecent = match
-						}
-					}
-				}
-				if mostRecent != "" {
-					matches = []string{mostRecent}
-					// If title was not extracted before, try to extract it now and rename file
-					if title == "" || strings.HasPrefix(title, "audio_") {
-						titleArgs := []string{"--no-cache-dir", "--print", "%(title)s", url}
-						if cookiesFile := e.getCookiesFile(url); cookiesFile != "" {
-							tempCookies := createTempCookies(cookiesFile)
-							titleArgs = append(titleArgs, "--cookies", tempCookies)
-						}
-						if strings.Contains(url, "tiktok.com") || strings.Contains(url, "vt.tiktok.com") {
-							titleArgs = append(titleArgs,
-								"--no-check-certificate",
-								"-4", // Force IPv4
-								"--legacy-server-connect",
-								"--user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
-								"--referer", "https://www.tiktok.com/",
-								"--add-header", "Accept:text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8",
-								"--add-header", "Accept-Language:en-US,en;q=0.9",
-								"--add-header", "Accept-Encoding:gzip, deflate, br",
-								"--add-header", "DNT:1",
-								"--add-header", "Connection:keep-alive",
-								"--add-header", "Upgrade-Insecure-Requests:1",
-								"--add-header", "Sec-Fetch-Dest:document",
-								"--add-header", "Sec-Fetch-Mode:navigate",
-								"--add-header", "Sec-Fetch-Site:none",
-								"--add-header", "Sec-Fetch-User:?1",
-							)
-						}
-						titleCmd := exec.CommandContext(ctx, "yt-dlp", titleArgs...)
-						if titleBytes, err := titleCmd.Output(); err == nil && len(titleBytes) > 0 {
-							newTitle := strings.TrimSpace(string(titleBytes))
-							newTitle = sanitizeFilename(newTitle)
-							if len(newTitle) > 220 {
-								newTitle = newTitle[:220]
-							}
-							if newTitle != "" && !strings.HasPrefix(newTitle, "audio_") {
-								// Rename file to use proper title
-								newPath := filepath.Join(e.config.TmpfsPath, newTitle+".mp3")
-								if err := os.Rename(mostRecent, newPath); err == nil {
-									matches = []string{newPath}
-								}
-							}
-						}
-					}
-				}
-			}
-		}
-	} else {
-		// For video, look for any file with jobID prefix
-		pattern := filepath.Join(e.config.TmpfsPath, jobID+"*")
-		matches, _ = filepath.Glob(pattern)
+
+	audio := tgbotapi.NewAudio(job.ChatID, tgbotapi.FilePath(media.Path))
+	audio.ReplyToMessageID = job.ReplyTo
+	audio.Title = media.Title
+	audio.Performer = media.Performer
+	audio.Duration = int(media.Duration)
+	audio.Caption = media.Caption
+	if media.ThumbPath != "" {
+		audio.Thumb = tgbotapi.FilePath(media.ThumbPath)
 	}
-	
-	if len(matches) == 0 {
-		return "", fmt.Errorf("downloaded file not found")
+
+	sent, err := e.botAPI.Send(audio)
+	if err != nil {
+		return fmt.Errorf("failed to send audio: %w", err)
 	}
+	media.rememberSent(sent)
+	return nil
+}
 
-	return matches[0], nil
+// chapterGroup builds a media group that replies to the original message
+func chapterGroup(job *queue.Job, group []interface{}) tgbotapi.MediaGroupConfig {
+	config := tgbotapi.NewMediaGroup(job.ChatID, group)
+	config.ReplyToMessageID = job.ReplyTo
+	return config
 }
 
-// getCookiesFile returns the appropriate cookies file path for the given URL
-// Returns empty string if no cookies file is configured for the platform
-func (e *Executor) getCookiesFile(url string) string {
-	// Check for platform-specific cookies files first
-	if strings.Contains(url, "tiktok.com") || strings.Contains(url, "vt.tiktok.com") {
-		if e.config.TikTokCookies != "" {
-			if _, err := os.Stat(e.config.TikTokCookies); err == nil {
-				return e.config.TikTokCookies
+// mediaGroupLimit is the maximum number of files in one Telegram media group
+const mediaGroupLimit = 10
+
+// sendAudioChapters sends chapter files as ordered media groups of up to 10 tracks
+func (e *Executor) sendAudioChapters(job *queue.Job, media *Media) error {
+	if e.botAPI == nil {
+		return fmt.Errorf("bot API not initialized")
+	}
+
+	for start := 0; start < len(media.Chapters); start += mediaGroupLimit {
+		end := min(start+mediaGroupLimit, len(media.Chapters))
+
+		var group []interface{}
+		for _, chapter := range media.Chapters[start:end] {
+			stat, err := os.Stat(chapter.Path)
+			if err != nil {
+				return fmt.Errorf("failed to stat chapter file: %w", err)
 			}
-		}
-	} else if strings.Contains(url, "instagram.com") || strings.Contains(url, "instagr.am") {
-		if e.config.InstagramCookies != "" {
-			if _, err := os.Stat(e.config.InstagramCookies); err == nil {
-				return e.config.InstagramCookies
+			if stat.Size() > e.config.MaxFileSize {
+				return fmt.Errorf("file too large: %d bytes (max: %d)", stat.Size(), e.config.MaxFileSize)
 			}
+
+			audio := tgbotapi.NewInputMediaAudio(tgbotapi.FilePath(chapter.Path))
+			audio.Title = chapter.Title
+			audio.Performer = chapter.Performer
+			audio.Duration = int(chapter.Duration)
+			group = append(group, audio)
 		}
-	} else if strings.Contains(url, "youtube.com") || strings.Contains(url, "youtu.be") {
-		if e.config.YouTubeCookies != "" {
-			if _, err := os.Stat(e.config.YouTubeCookies); err == nil {
-				return e.config.YouTubeCookies
+
+		// A single file can't form a group
+		if len(group) == 1 {
+			if err := e.sendAudio(job, media.Chapters[start]); err != nil {
+				return err
 			}
+			continue
 		}
-	}
-	
-	// Fallback to deprecated COOKIES_FILE for backward compatibility
-	if e.config.CookiesFile != "" {
-		if _, err := os.Stat(e.config.CookiesFile); err == nil {
-			return e.config.CookiesFile
-		}
-	}
-	
-	return ""
-}
 
-// sanitizeFilename removes or replaces characters that are invalid in filenames
-func sanitizeFilename(name string) string {
-	// Replace invalid filesystem characters
-	invalidChars := []string{"/", "\\", ":", "*", "?", "\"", "<", ">", "|", "\x00"}
-	result := name
-	for _, char := range invalidChars {
-		result = strings.ReplaceAll(result, char, "_")
-	}
-	// Remove leading/trailing spaces and dots
-	result = strings.Trim(result, " .")
-	// Replace multiple spaces/underscores with single underscore
-	result = strings.ReplaceAll(result, "  ", " ")
-	result = strings.ReplaceAll(result, "__", "_")
-	return result
-}
-
-func (e *Executor) getFormatForQuality(quality string) string {
-	switch quality {
-	case "1080p":
-		return "bestvideo[height<=1080][ext=mp4]+bestaudio[ext=m4a]/best[height<=1080][ext=mp4]/best"
-	case "720p":
-		return "bestvideo[height<=720][ext=mp4]+bestaudio[ext=m4a]/best[height<=720][ext=mp4]/best"
-	case "480p":
-		return "bestvideo[height<=480][ext=mp4]+bestaudio[ext=m4a]/best[height<=480][ext=mp4]/best"
-	case "360p":
-		return "bestvideo[height<=360][ext=mp4]+bestaudio[ext=m4a]/best[height<=360][ext=mp4]/best"
-	case "audio":
-		return "bestaudio[ext=m4a]/bestaudio/best"
-	default: // "best"
-		return "bestvideo[ext=mp4]+bestaudio[ext=m4a]/best[ext=mp4]/best"
+		if _, err := e.botAPI.SendMediaGroup(chapterGroup(job, group)); err != nil {
+			return fmt.Errorf("failed to send chapters %d-%d: %w", start+1, end, err)
+		}
 	}
+	return nil
 }
 
-func (e *Executor) sendAudio(chatID int64, audioPath string) error {
+func (e *Executor) sendVideo(job *queue.Job, media *Media) error {
 	if e.botAPI == nil {
 		return fmt.Errorf("bot API not initialized")
 	}
 
-	file, err := os.Open(audioPath)
+	file, err := os.Open(media.Path)
 	if err != nil {
-		return fmt.Errorf("failed to open audio file: %w", err)
+		return fmt.Errorf("failed to open video file: %w", err)
 	}
 	defer file.Close()
 
 	stat, err := file.Stat()
 	if err != nil {
-		return fmt.Errorf("failed to stat audio file: %w", err)
+		return fmt.Errorf("failed to stat video file: %w", err)
 	}
 
 	// Check file size
@@ -480,51 +366,224 @@ func (e *Executor) sendAudio(chatID int64, audioPath string) error {
 		return fmt.Errorf("file too large: %d bytes (max: %d)", stat.Size(), e.config.MaxFileSize)
 	}
 
-	audio := tgbotapi.NewAudio(chatID, tgbotapi.FilePath(audioPath))
+	video := tgbotapi.NewVideo(job.ChatID, tgbotapi.FilePath(media.Path))
+	video.ReplyToMessageID = job.ReplyTo
+	video.SupportsStreaming = true
+	video.Duration = int(media.Duration)
+	video.Caption = media.Caption
 
-	_, err = e.botAPI.Send(audio)
+	sent, err := e.botAPI.Send(video)
 	if err != nil {
-		return fmt.Errorf("failed to send audio: %w", err)
+		return fmt.Errorf("failed to send video: %w", err)
 	}
+	media.rememberSent(sent)
 	return nil
 }
 
-func (e *Executor) sendVideo(chatID int64, videoPath string) error {
+// durationLimitError is returned when media is too long for the delivery mode
+type durationLimitError struct {
+	duration float64
+	limit    int
+}
+
+func (err *durationLimitError) Error() string {
+	return fmt.Sprintf("media is %.0fs long, limit is %ds", err.duration, err.limit)
+}
+
+// deliveryErrorMessage builds the user message for a failed voice/video note upload
+func deliveryErrorMessage(lang string, err error, what string) string {
+	var tooLong *durationLimitError
+	if errors.As(err, &tooLong) {
+		return i18n.T(lang, "exec.too_long", what, int(tooLong.duration), tooLong.limit, min(tooLong.limit, 59))
+	}
+	return i18n.T(lang, "exec.send_failed", what)
+}
+
+// checkDuration probes the duration if the downloader didn't report it
+// and compares it with the limit
+func checkDuration(ctx context.Context, media *Media, limit int) error {
+	if media.Duration == 0 {
+		if info, err := probeMedia(ctx, media.Path); err == nil {
+			media.Duration = info.duration()
+		}
+	}
+	if media.Duration > float64(limit) {
+		return &durationLimitError{duration: media.Duration, limit: limit}
+	}
+	return nil
+}
+
+// checkFileSize rejects converted files over MaxFileSize, like sendVideo
+// and sendAudio do for downloaded ones
+func (e *Executor) checkFileSize(path string) error {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	if stat.Size() > e.config.MaxFileSize {
+		return fmt.Errorf("file too large: %d bytes (max: %d)", stat.Size(), e.config.MaxFileSize)
+	}
+	return nil
+}
+
+// sendVoice converts the media to OGG/Opus and sends it as a voice message
+func (e *Executor) sendVoice(ctx context.Context, job *queue.Job, media *Media) error {
 	if e.botAPI == nil {
 		return fmt.Errorf("bot API not initialized")
 	}
+	if err := checkDuration(ctx, media, e.config.VoiceMaxSeconds); err != nil {
+		return err
+	}
 
-	file, err := os.Open(videoPath)
+	voicePath, err := convertToVoice(ctx, media.Path)
 	if err != nil {
-		return fmt.Errorf("failed to open video file: %w", err)
+		return err
+	}
+	media.addTempFile(voicePath)
+	if err := e.checkFileSize(voicePath); err != nil {
+		return err
 	}
-	defer file.Close()
 
-	stat, err := file.Stat()
+	voice := tgbotapi.NewVoice(job.ChatID, tgbotapi.FilePath(voicePath))
+	voice.ReplyToMessageID = job.ReplyTo
+	voice.Duration = int(media.Duration)
+	voice.Caption = media.Caption
+
+	sent, err := e.botAPI.Send(voice)
 	if err != nil {
-		return fmt.Errorf("failed to stat video file: %w", err)
+		return fmt.Errorf("failed to send voice: %w", err)
 	}
+	media.rememberSent(sent)
+	return nil
+}
 
-	// Check file size
-	if stat.Size() > e.config.MaxFileSize {
-		return fmt.Errorf("file too large: %d bytes (max: %d)", stat.Size(), e.config.MaxFileSize)
+// sendVideoNote converts the video to a square clip and sends it as a round video note
+func (e *Executor) sendVideoNote(ctx context.Context, job *queue.Job, media *Media) error {
+	if e.botAPI == nil {
+		return fmt.Errorf("bot API not initialized")
+	}
+	if err := checkDuration(ctx, media, e.config.VideoNoteMaxSeconds); err != nil {
+		return err
 	}
 
-	video := tgbotapi.NewVideo(chatID, tgbotapi.FilePath(videoPath))
-	video.SupportsStreaming = true
+	notePath, err := convertToVideoNote(ctx, media.Path)
+	if err != nil {
+		return err
+	}
+	media.addTempFile(notePath)
+	if err := e.checkFileSize(notePath); err != nil {
+		return err
+	}
 
-	_, err = e.botAPI.Send(video)
+	note := tgbotapi.NewVideoNote(job.ChatID, videoNoteSize, tgbotapi.FilePath(notePath))
+	note.ReplyToMessageID = job.ReplyTo
+	note.Duration = int(media.Duration)
+
+	sent, err := e.botAPI.Send(note)
 	if err != nil {
-		return fmt.Errorf("failed to send video: %w", err)
+		return fmt.Errorf("failed to send video note: %w", err)
 	}
+	media.rememberSent(sent)
 	return nil
 }
 
-func (e *Executor) sendMessage(chatID int64, text string) {
+// sendAnimation converts the clip to a silent short MP4 and sends it as a GIF
+func (e *Executor) sendAnimation(ctx context.Context, job *queue.Job, media *Media) error {
+	if e.botAPI == nil {
+		return fmt.Errorf("bot API not initialized")
+	}
+
+	animPath, err := convertToAnimation(ctx, media.Path, e.config.AnimationMaxSeconds, e.config.AnimationMaxWidth)
+	if err != nil {
+		return err
+	}
+	media.addTempFile(animPath)
+	if err := e.checkFileSize(animPath); err != nil {
+		return err
+	}
+
+	animation := tgbotapi.NewAnimation(job.ChatID, tgbotapi.FilePath(animPath))
+	animation.ReplyToMessageID = job.ReplyTo
+	if media.Duration > 0 {
+		animation.Duration = min(int(media.Duration), e.config.AnimationMaxSeconds)
+	}
+
+	sent, err := e.botAPI.Send(animation)
+	if err != nil {
+		return fmt.Errorf("failed to send animation: %w", err)
+	}
+	media.rememberSent(sent)
+	return nil
+}
+
+func (e *Executor) sendDocument(job *queue.Job, path string) error {
+	if e.botAPI == nil {
+		return fmt.Errorf("bot API not initialized")
+	}
+
+	doc := tgbotapi.NewDocument(job.ChatID, tgbotapi.FilePath(path))
+	doc.ReplyToMessageID = job.ReplyTo
+	if _, err := e.botAPI.Send(doc); err != nil {
+		return fmt.Errorf("failed to send document: %w", err)
+	}
+	return nil
+}
+
+// formatDuration renders seconds as "1 мин 23 сек" or "45 сек"
+func formatDuration(lang string, seconds float64) string {
+	s := int(seconds + 0.5)
+	if s >= 60 {
+		return i18n.T(lang, "duration.min_sec", s/60, s%60)
+	}
+	return i18n.T(lang, "duration.sec", s)
+}
+
+// recordTraffic counts the delivered files towards the chat's daily traffic quota
+func (e *Executor) recordTraffic(q queue.Queue, job *queue.Job, media *Media) {
+	client := q.GetClient()
+	if client == nil {
+		return
+	}
+
+	files := []*Media{media}
+	if len(media.Chapters) > 0 {
+		files = media.Chapters
+	}
+	var size int64
+	for _, file := range files {
+		if stat, err := os.Stat(file.Path); err == nil {
+			size += stat.Size()
+		}
+	}
+	ratelimit.New(client).AddBytes(job.QuotaChatID(), size)
+}
+
+// recordHistory adds the download to the chat's history for /history.
+// Inline results are delivered elsewhere and aren't recorded.
+func (e *Executor) recordHistory(job *queue.Job, media *Media) {
+	if e.history == nil {
+		return
+	}
+	entry := &history.Entry{
+		Job:   job,
+		Title: media.Title,
+		Date:  time.Now(),
+	}
+	// Chapters are several files, they are downloaded again instead
+	if len(media.Chapters) == 0 {
+		entry.File = media.sent
+	}
+	if err := e.history.Add(job.ChatID, entry); err != nil {
+		log.Printf("Failed to record history: %v", err)
+	}
+}
+
+func (e *Executor) sendMessage(chatID int64, replyTo int, text string) {
 	if e.botAPI == nil {
 		return
 	}
 	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyToMessageID = replyTo
 	e.botAPI.Send(msg)
 }
 
@@ -0,0 +1,217 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// runFFmpeg runs ffmpeg with the given arguments and returns its output on failure
+func runFFmpeg(ctx context.Context, args ...string) error {
+	args = append([]string{"-hide_banner", "-loglevel", "error", "-y"}, args...)
+	output, err := exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg failed: %w\nOutput: %s", err, output)
+	}
+	return nil
+}
+
+// trimMedia cuts [start, end] out of the file without re-encoding and
+// replaces the original. end == 0 keeps everything after start.
+func trimMedia(ctx context.Context, path string, start, end float64) error {
+	ext := filepath.Ext(path)
+	tmpPath := strings.TrimSuffix(path, ext) + ".clip" + ext
+
+	args := []string{"-ss", formatSeconds(start)}
+	if end > 0 {
+		args = append(args, "-to", formatSeconds(end))
+	}
+	args = append(args, "-i", path, "-c", "copy", "-map", "0", "-avoid_negative_ts", "make_zero", tmpPath)
+
+	if err := runFFmpeg(ctx, args...); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// formatSeconds renders seconds the way ffmpeg and yt-dlp accept them
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', -1, 64)
+}
+
+// makeTelegramThumb scales an image down to a JPEG Telegram accepts
+// as a thumbnail (at most 320px on the longest side)
+func makeTelegramThumb(ctx context.Context, imagePath string) (string, error) {
+	thumbPath := strings.TrimSuffix(imagePath, filepath.Ext(imagePath)) + ".thumb.jpg"
+	err := runFFmpeg(ctx,
+		"-i", imagePath,
+		"-vf", "scale='min(320,iw)':'min(320,ih)':force_original_aspect_ratio=decrease",
+		"-frames:v", "1", "-q:v", "5",
+		thumbPath,
+	)
+	if err != nil {
+		os.Remove(thumbPath)
+		return "", err
+	}
+	return thumbPath, nil
+}
+
+// probeInfo is the subset of ffprobe output the executor uses
+type probeInfo struct {
+	Format struct {
+		Duration string            `json:"duration"`
+		Tags     map[string]string `json:"tags"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType string `json:"codec_type"`
+		CodecName string `json:"codec_name"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+	} `json:"streams"`
+}
+
+// probeMedia runs ffprobe on the file
+func probeMedia(ctx context.Context, path string) (*probeInfo, error) {
+	output, err := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-show_entries", "format=duration:format_tags:stream=codec_type,codec_name,width,height",
+		"-of", "json",
+		path,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	var info probeInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+	return &info, nil
+}
+
+// duration returns the container duration in seconds, 0 if unknown
+func (p *probeInfo) duration() float64 {
+	d, _ := strconv.ParseFloat(p.Format.Duration, 64)
+	return d
+}
+
+// tag returns a format tag regardless of its case (ID3 uses "TITLE" or "title")
+func (p *probeInfo) tag(name string) string {
+	for k, v := range p.Format.Tags {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// convertToVoice re-encodes any audio or video file into the mono OGG/Opus
+// stream Telegram requires for voice messages
+func convertToVoice(ctx context.Context, path string) (string, error) {
+	outPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".voice.ogg"
+	err := runFFmpeg(ctx,
+		"-i", path,
+		"-vn", "-map_metadata", "-1",
+		"-c:a", "libopus", "-b:a", "64k", "-ac", "1", "-ar", "48000",
+		outPath,
+	)
+	if err != nil {
+		os.Remove(outPath)
+		return "", err
+	}
+	return outPath, nil
+}
+
+// videoNoteSize is the side of the square video note in pixels (Telegram maximum)
+const videoNoteSize = 640
+
+// convertToVideoNote crops the center square of the video and scales it
+// to the size Telegram uses for round video notes
+func convertToVideoNote(ctx context.Context, path string) (string, error) {
+	outPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".note.mp4"
+	err := runFFmpeg(ctx,
+		"-i", path,
+		"-vf", fmt.Sprintf("crop='min(iw,ih)':'min(iw,ih)',scale=%d:%d,setsar=1", videoNoteSize, videoNoteSize),
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "26", "-pix_fmt", "yuv420p",
+		"-c:a", "aac", "-b:a", "96k",
+		"-movflags", "+faststart",
+		outPath,
+	)
+	if err != nil {
+		os.Remove(outPath)
+		return "", err
+	}
+	return outPath, nil
+}
+
+// convertToAnimation turns a clip into a silent H.264 MP4, which Telegram
+// shows as a GIF. The clip is cut to maxSeconds and scaled down to maxWidth.
+func convertToAnimation(ctx context.Context, path string, maxSeconds, maxWidth int) (string, error) {
+	outPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".anim.mp4"
+	err := runFFmpeg(ctx,
+		"-i", path,
+		"-t", strconv.Itoa(maxSeconds),
+		"-an", "-map_metadata", "-1",
+		"-vf", fmt.Sprintf("scale='min(%d,iw)':-2", maxWidth),
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "28", "-pix_fmt", "yuv420p",
+		"-movflags", "+faststart",
+		outPath,
+	)
+	if err != nil {
+		os.Remove(outPath)
+		return "", err
+	}
+	return outPath, nil
+}
+
+// burnSubtitles renders the subtitles into the video picture.
+// Returns the path of the new MP4 file.
+func burnSubtitles(ctx context.Context, path, subsPath string) (string, error) {
+	outPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".subs.mp4"
+	err := runFFmpeg(ctx,
+		"-i", path,
+		"-vf", "subtitles="+escapeFilterPath(subsPath),
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-pix_fmt", "yuv420p",
+		"-c:a", "aac", "-b:a", "160k",
+		"-movflags", "+faststart",
+		outPath,
+	)
+	if err != nil {
+		os.Remove(outPath)
+		return "", err
+	}
+	return outPath, nil
+}
+
+// escapeFilterPath escapes a file path for use inside an ffmpeg filter graph
+func escapeFilterPath(path string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `:`, `\:`, `'`, `\'`, `,`, `\,`, `[`, `\[`, `]`, `\]`)
+	return replacer.Replace(path)
+}
+
+// tagAudio rewrites the file's tags without re-encoding. Existing tags
+// and cover art are kept unless overridden.
+func tagAudio(ctx context.Context, path string, tags map[string]string) error {
+	ext := filepath.Ext(path)
+	tmpPath := strings.TrimSuffix(path, ext) + ".tagged" + ext
+
+	args := []string{"-i", path, "-map", "0", "-c", "copy"}
+	for key, value := range tags {
+		if value != "" {
+			args = append(args, "-metadata", key+"="+value)
+		}
+	}
+	args = append(args, tmpPath)
+
+	if err := runFFmpeg(ctx, args...); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
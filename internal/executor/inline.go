@@ -0,0 +1,128 @@
+package executor
+
+import (
+	"fmt"
+	"log"
+
+	"envedour-bot/internal/i18n"
+	"envedour-bot/internal/queue"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// rememberSent records the uploaded file so it can be cached and reused
+func (m *Media) rememberSent(msg tgbotapi.Message) {
+	file := &queue.CachedFile{Title: m.Title}
+	switch {
+	case msg.Video != nil:
+		file.FileID, file.Type = msg.Video.FileID, "video"
+	case msg.Audio != nil:
+		file.FileID, file.Type = msg.Audio.FileID, "audio"
+	case msg.Voice != nil:
+		file.FileID, file.Type = msg.Voice.FileID, "voice"
+	case msg.VideoNote != nil:
+		file.FileID, file.Type = msg.VideoNote.FileID, "video_note"
+	case msg.Animation != nil:
+		file.FileID, file.Type = msg.Animation.FileID, "animation"
+	default:
+		return
+	}
+	m.sent = file
+	m.sentMsgID = msg.MessageID
+}
+
+// cacheFile saves the uploaded file ID under the job's cache key
+func (e *Executor) cacheFile(q queue.Queue, job *queue.Job, media *Media) {
+	key := job.CacheKey()
+	if key == "" || media.sent == nil {
+		return
+	}
+	if err := q.SaveFile(key, media.sent); err != nil {
+		log.Printf("Failed to cache file ID: %v", err)
+	}
+}
+
+// deliverCachedInline puts an already cached file into the inline message.
+// Returns false if there is nothing cached yet.
+func (e *Executor) deliverCachedInline(q queue.Queue, job *queue.Job) bool {
+	key := job.CacheKey()
+	if key == "" {
+		return false
+	}
+	file, err := q.GetFile(key)
+	if err != nil {
+		return false
+	}
+	if err := e.editInlineMedia(job.InlineMessage, file, ""); err != nil {
+		log.Printf("Inline edit error: %v", err)
+		return false
+	}
+	return true
+}
+
+// deliverInline replaces the inline placeholder with the uploaded file. Without
+// a dedicated upload chat the file went to the user's chat and is removed from there.
+func (e *Executor) deliverInline(job *queue.Job, media *Media) {
+	if media.sent == nil {
+		return
+	}
+	if err := e.editInlineMedia(job.InlineMessage, media.sent, media.Caption); err != nil {
+		log.Printf("Inline edit error: %v", err)
+		e.notify(job, i18n.T(job.Lang, "inline.send_failed"))
+		return
+	}
+	if e.config.InlineChatID == 0 && e.botAPI != nil {
+		e.botAPI.Request(tgbotapi.NewDeleteMessage(job.ChatID, media.sentMsgID))
+	}
+}
+
+// editInlineMedia swaps the content of an inline message for an uploaded file
+func (e *Executor) editInlineMedia(inlineMessageID string, file *queue.CachedFile, caption string) error {
+	if e.botAPI == nil {
+		return fmt.Errorf("bot API not initialized")
+	}
+
+	var media interface{}
+	switch file.Type {
+	case "video":
+		video := tgbotapi.NewInputMediaVideo(tgbotapi.FileID(file.FileID))
+		video.SupportsStreaming = true
+		video.Caption = caption
+		media = video
+	case "audio":
+		audio := tgbotapi.NewInputMediaAudio(tgbotapi.FileID(file.FileID))
+		audio.Caption = caption
+		media = audio
+	case "animation":
+		media = tgbotapi.NewInputMediaAnimation(tgbotapi.FileID(file.FileID))
+	default:
+		return fmt.Errorf("%s can't be sent as an inline result", file.Type)
+	}
+
+	edit := tgbotapi.EditMessageMediaConfig{
+		BaseEdit: tgbotapi.BaseEdit{InlineMessageID: inlineMessageID},
+		Media:    media,
+	}
+	// Inline edits return true instead of a message, so Send can't be used
+	if _, err := e.botAPI.Request(edit); err != nil {
+		return fmt.Errorf("failed to edit inline message: %w", err)
+	}
+	return nil
+}
+
+// notify reports job progress or errors to the user. Inline jobs have no
+// chat with the user, so the inline message itself is updated.
+func (e *Executor) notify(job *queue.Job, text string) {
+	if job.InlineMessage == "" {
+		e.sendMessage(job.ChatID, job.ReplyTo, text)
+		return
+	}
+	if e.botAPI == nil {
+		return
+	}
+	edit := tgbotapi.EditMessageTextConfig{
+		BaseEdit: tgbotapi.BaseEdit{InlineMessageID: job.InlineMessage},
+		Text:     text,
+	}
+	e.botAPI.Request(edit)
+}
@@ -0,0 +1,174 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"envedour-bot/internal/config"
+	"envedour-bot/internal/donor"
+	"envedour-bot/internal/i18n"
+	"envedour-bot/internal/queue"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// errLiveStream is returned by the downloader when the URL is a live stream
+// and the job doesn't say how long to record it
+var errLiveStream = errors.New("live stream")
+
+// ytdlpRejectedExitCode is the yt-dlp exit code when --break-match-filters rejects a video
+const ytdlpRejectedExitCode = 101
+
+// liveStopGrace is how long yt-dlp gets to finalize the file after being interrupted
+const liveStopGrace = 60 * time.Second
+
+// liveRecordOptions are the recording lengths offered to users, in minutes
+var liveRecordOptions = []int{5, 15, 30, 60, 120, 240}
+
+// record captures a live stream from now for job.RecordSeconds. yt-dlp is
+// interrupted with SIGINT when the time is up so it finalizes the file.
+func (d *ytdlpDownloader) record(ctx context.Context, job *queue.Job, platformArgs []string) (*Media, error) {
+	format := getFormatForQuality(job.Quality)
+	if job.MediaType == "audio" {
+		format = "bestaudio/best"
+	}
+
+	args := []string{
+		"--no-cache-dir",
+		"--no-part",
+		"--hls-use-mpegts", // Readable even if recording is cut off
+		"--no-cookies-from-browser",
+		"--format", format,
+		"-o", filepath.Join(d.config.TmpfsPath, job.ID+".%(ext)s"),
+	}
+	args = append(args, platformArgs...)
+	args = append(args, job.URL)
+
+	cmd := exec.CommandContext(ctx, "yt-dlp", args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start yt-dlp: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var waitErr error
+	select {
+	case waitErr = <-done:
+		// Stream ended before the time was up
+	case <-time.After(time.Duration(job.RecordSeconds) * time.Second):
+		cmd.Process.Signal(syscall.SIGINT)
+		select {
+		case <-done:
+		case <-time.After(liveStopGrace):
+			cmd.Process.Kill()
+			<-done
+		}
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(d.config.TmpfsPath, job.ID+".*"))
+	if len(matches) == 0 {
+		if waitErr != nil {
+			return nil, fmt.Errorf("yt-dlp failed: %w\nOutput: %s", waitErr, stderr.String())
+		}
+		return nil, fmt.Errorf("recorded file not found")
+	}
+
+	// MPEG-TS isn't streamable in Telegram, remux into MP4/M4A
+	ext := ".mp4"
+	remuxArgs := []string{"-c", "copy", "-movflags", "+faststart"}
+	if job.MediaType == "audio" {
+		ext = ".m4a"
+		remuxArgs = append([]string{"-vn"}, remuxArgs...)
+	}
+	outPath := filepath.Join(d.config.TmpfsPath, job.ID+".rec"+ext)
+	err := runFFmpeg(ctx, append(append([]string{"-i", matches[0]}, remuxArgs...), outPath)...)
+	os.Remove(matches[0])
+	if err != nil {
+		os.Remove(outPath)
+		return nil, err
+	}
+
+	media := &Media{Path: outPath, Title: fmt.Sprintf("live_%s", time.Now().Format("2006-01-02_15-04"))}
+	if info, err := probeMedia(ctx, outPath); err == nil {
+		media.Duration = info.duration()
+	}
+	return media, nil
+}
+
+// maxRecordMinutes returns the recording cap for the chat
+func (e *Executor) maxRecordMinutes(q queue.Queue, chatID int64) int {
+	return MaxRecordMinutes(e.config, donor.NewStore(q.GetClient(), e.config.DonorChatIDs).Tier(chatID))
+}
+
+// MaxRecordMinutes returns the recording cap for the donor tier, "" for non-donors
+func MaxRecordMinutes(cfg *config.Config, tier string) int {
+	switch tier {
+	case donor.TierPremium:
+		return cfg.LivePremiumMaxMinutes
+	case donor.TierBasic:
+		return cfg.LiveDonorMaxMinutes
+	}
+	return cfg.LiveMaxMinutes
+}
+
+// offerRecording parks the job and asks the user how long to record the stream
+func (e *Executor) offerRecording(q queue.Queue, job *queue.Job) {
+	if err := q.SavePending(job); err != nil {
+		log.Printf("Failed to save pending live job: %v", err)
+		e.notify(job, i18n.T(job.Lang, "live.unavailable"))
+		return
+	}
+
+	maxMinutes := e.maxRecordMinutes(q, job.ChatID)
+	var row []tgbotapi.InlineKeyboardButton
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, minutes := range recordOptions(maxMinutes) {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			i18n.T(job.Lang, "live.button", minutes),
+			fmt.Sprintf("live_%d:%s", minutes, job.ID),
+		))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(job.Lang, "live.cancel"), "live_cancel:"+job.ID),
+	))
+
+	text := i18n.T(job.Lang, "live.offer", maxMinutes)
+	msg := tgbotapi.NewMessage(job.ChatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	msg.ReplyToMessageID = job.ReplyTo
+	if e.botAPI != nil {
+		e.botAPI.Send(msg)
+	}
+}
+
+// recordOptions returns the recording lengths in minutes available under the cap
+func recordOptions(maxMinutes int) []int {
+	var options []int
+	for _, minutes := range liveRecordOptions {
+		if minutes <= maxMinutes {
+			options = append(options, minutes)
+		}
+	}
+	if len(options) == 0 || options[len(options)-1] < maxMinutes {
+		options = append(options, maxMinutes)
+	}
+	return options
+}
@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// SearchLimit is how many results a text search returns
+const SearchLimit = 5
+
+// searchTimeout bounds a single search request
+const searchTimeout = 30 * time.Second
+
+// SearchResult is a single search hit
+type SearchResult struct {
+	Title    string  `json:"title"`
+	URL      string  `json:"url"`
+	Duration float64 `json:"duration,omitempty"`
+}
+
+// Search looks up the query on YouTube, or on SoundCloud if soundcloud is set.
+// Results are flat (not fully extracted) so the search stays fast.
+func (e *Executor) Search(ctx context.Context, query string, soundcloud bool) ([]SearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+
+	prefix := "ytsearch"
+	if soundcloud {
+		prefix = "scsearch"
+	}
+	args := []string{
+		"--no-cache-dir",
+		"--flat-playlist",
+		"--dump-json",
+		fmt.Sprintf("%s%d:%s", prefix, SearchLimit, query),
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "yt-dlp", args...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w\nOutput: %s", err, stderr.String())
+	}
+
+	var results []SearchResult
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var entry struct {
+			Title      string  `json:"title"`
+			URL        string  `json:"url"`
+			WebpageURL string  `json:"webpage_url"`
+			Duration   float64 `json:"duration"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		url := entry.WebpageURL
+		if url == "" {
+			url = entry.URL
+		}
+		if !strings.HasPrefix(url, "http") {
+			continue
+		}
+		title := strings.TrimSpace(entry.Title)
+		if title == "" {
+			title = url
+		}
+		results = append(results, SearchResult{Title: title, URL: url, Duration: entry.Duration})
+	}
+	return results, nil
+}
@@ -0,0 +1,48 @@
+package executor
+
+import (
+	"time"
+
+	"envedour-bot/internal/queue"
+)
+
+// WorkerState is a snapshot of what a worker is doing
+type WorkerState struct {
+	ID    int
+	Job   *queue.Job // nil when the worker is idle
+	Since time.Time  // When the worker started the job or became idle
+}
+
+// addWorker registers a new worker and returns its ID
+func (e *Executor) addWorker() int {
+	e.workersMu.Lock()
+	defer e.workersMu.Unlock()
+	id := len(e.workers) + 1
+	e.workers = append(e.workers, WorkerState{ID: id, Since: time.Now()})
+	return id
+}
+
+// setWorkerJob records the job a worker is busy with, nil when it's done.
+// It keeps a copy since processJob changes the job while /workers reads it.
+func (e *Executor) setWorkerJob(id int, job *queue.Job) {
+	if job != nil {
+		j := *job
+		job = &j
+	}
+	e.workersMu.Lock()
+	defer e.workersMu.Unlock()
+	e.workers[id-1].Job = job
+	e.workers[id-1].Since = time.Now()
+}
+
+// Workers returns the current state of all workers
+func (e *Executor) Workers() []WorkerState {
+	e.workersMu.Lock()
+	defer e.workersMu.Unlock()
+	return append([]WorkerState(nil), e.workers...)
+}
+
+// IsThrottled reports whether jobs are being rejected because of overheating
+func (e *Executor) IsThrottled() bool {
+	return e.armOptimized && e.thermalMon != nil && e.thermalMon.IsThrottled()
+}
@@ -0,0 +1,475 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"envedour-bot/internal/config"
+	"envedour-bot/internal/extractor"
+	"envedour-bot/internal/queue"
+)
+
+// ytdlpDownloader downloads anything yt-dlp understands. Platform quirks
+// (headers, cookies) come from the extractor registry.
+type ytdlpDownloader struct {
+	config       *config.Config
+	armOptimized bool
+}
+
+func newYtdlpDownloader(cfg *config.Config, armOptimized bool) *ytdlpDownloader {
+	return &ytdlpDownloader{config: cfg, armOptimized: armOptimized}
+}
+
+// Match always returns true: yt-dlp is the fallback for every URL
+func (d *ytdlpDownloader) Match(job *queue.Job) bool {
+	return true
+}
+
+func (d *ytdlpDownloader) Download(ctx context.Context, job *queue.Job) (*Media, error) {
+	ex := extractor.ForURL(job.URL)
+
+	var outputPath string
+	var title string
+	var tempCookiesFiles []string // Track temp cookies files for cleanup
+
+	// Cleanup temp cookies files at the end
+	defer func() {
+		for _, tempFile := range tempCookiesFiles {
+			os.Remove(tempFile)
+		}
+	}()
+
+	// platformArgs returns extractor args plus a temporary copy of the cookies
+	// file, so yt-dlp can't fail on permissions when it tries to save them
+	platformArgs := func() []string {
+		args := extractor.YtdlpArgs(ex)
+		if cookiesFile := extractor.CookiesFile(ex, d.config); cookiesFile != "" {
+			tempCookies := d.createTempCookies(job.ID, cookiesFile)
+			if tempCookies != cookiesFile {
+				tempCookiesFiles = append(tempCookiesFiles, tempCookies)
+			}
+			args = append(args, "--cookies", tempCookies)
+		}
+		return args
+	}
+
+	// Live streams are recorded for the requested time instead
+	if job.RecordSeconds > 0 {
+		return d.record(ctx, job, platformArgs())
+	}
+
+	// For audio, get title first to use in filename (to avoid truncation)
+	if job.MediaType == "audio" {
+		title = d.fetchTitle(ctx, job.URL, platformArgs())
+		// If title extraction failed or empty, use timestamp-based name
+		if title == "" {
+			title = fmt.Sprintf("audio_%d", time.Now().Unix())
+		}
+		// Use only title in output path (no jobID prefix)
+		outputPath = filepath.Join(d.config.TmpfsPath, fmt.Sprintf("%s.%%(ext)s", title))
+	} else {
+		outputPath = filepath.Join(d.config.TmpfsPath, fmt.Sprintf("%s.%%(ext)s", job.ID))
+	}
+
+	// Build yt-dlp command arguments
+	args := []string{
+		"--no-cache-dir",
+		"--external-downloader", "aria2c",
+		"--external-downloader-args", "aria2c:-j16 -x16 -s16 --file-allocation=falloc --stream-piece-selector=geom --max-download-limit=0",
+		"-o", outputPath,
+		// Disable automatic cookie extraction from browsers (server doesn't have browsers)
+		"--no-cookies-from-browser",
+	}
+
+	// Set format based on media type and quality
+	if job.MediaType == "audio" {
+		args = append(args, audioArgs(job.AudioFormat, job.AudioBitrate)...)
+	} else {
+		args = append(args, "--format", getFormatForQuality(job.Quality))
+	}
+
+	// Embed tags and cover art into audio files. The thumbnail is kept on
+	// disk as well, it's sent to Telegram as the track artwork.
+	if job.MediaType == "audio" && job.EmbedTags {
+		args = append(args,
+			"--embed-metadata",
+			"--parse-metadata", "%(artist,creator,uploader)s:%(meta_artist)s",
+			"--write-thumbnail", "--embed-thumbnail", "--convert-thumbnails", "jpg",
+		)
+	}
+
+	// Subtitles are saved next to the video as <jobID>.<lang>.srt
+	if job.MediaType == "video" && job.SubMode != "" && job.SubLang != "" {
+		args = append(args, "--write-subs", "--sub-langs", job.SubLang, "--convert-subs", "srt")
+		if job.SubAuto {
+			// yt-dlp prefers manual subtitles when both exist
+			args = append(args, "--write-auto-subs")
+		}
+	}
+
+	// Cut sponsor segments where the platform supports SponsorBlock
+	if job.SponsorBlock && ex.SponsorBlock() {
+		args = append(args, "--sponsorblock-remove", strings.Join(sponsorBlockCategories, ","))
+		if d.config.SponsorBlockAPI != "" {
+			args = append(args, "--sponsorblock-api", d.config.SponsorBlockAPI)
+		}
+	}
+
+	// Chapters are written as <jobID>_ch001.<ext>, <jobID>_ch002.<ext>, ...
+	if job.MediaType == "audio" && job.Chapters {
+		chapterPath := filepath.Join(d.config.TmpfsPath, job.ID+"_ch%(section_number)03d.%(ext)s")
+		args = append(args, "--split-chapters", "-o", "chapter:"+chapterPath)
+	}
+
+	// A live stream would block the worker until it ends, stop and ask
+	// the user how long to record instead
+	args = append(args, "--break-match-filters", "!is_live")
+
+	// Print metadata of the final file once all post-processing is done
+	args = append(args, "--print", "after_move:"+ytdlpInfoTemplate)
+
+	// Animations are cut to a short length anyway, don't fetch the rest
+	if job.MediaType == "animation" && !job.IsClipped() {
+		args = append(args, "--download-sections", fmt.Sprintf("*0-%d", d.config.AnimationMaxSeconds))
+	}
+
+	// Download only the requested fragment
+	if job.IsClipped() {
+		end := "inf"
+		if job.ClipEnd > 0 {
+			end = formatSeconds(job.ClipEnd)
+		}
+		args = append(args,
+			"--download-sections", fmt.Sprintf("*%s-%s", formatSeconds(job.ClipStart), end),
+			"--force-keyframes-at-cuts",
+		)
+	}
+
+	if extractor.CookiesFile(ex, d.config) == "" && ex.RequiresCookies() {
+		log.Printf("Warning: %s URL detected but no cookies file configured. %s may require cookies to bypass 403 errors.", ex.Name(), ex.Name())
+	}
+	args = append(args, platformArgs()...)
+
+	// Add URL at the end
+	args = append(args, job.URL)
+
+	// Use yt-dlp to get video info and download
+	ytdlpCmd := exec.CommandContext(ctx, "yt-dlp", args...)
+
+	ytdlpCmd.Env = os.Environ()
+	if d.armOptimized && runtime.GOARCH == "arm64" {
+		// Set ARM-specific environment
+		ytdlpCmd.Env = append(ytdlpCmd.Env, "FFMPEG_BINARY=ffmpeg")
+	}
+
+	var stdout, stderr bytes.Buffer
+	ytdlpCmd.Stdout = &stdout
+	ytdlpCmd.Stderr = &stderr
+	if err := ytdlpCmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == ytdlpRejectedExitCode {
+			return nil, errLiveStream
+		}
+		return nil, fmt.Errorf("yt-dlp failed: %w\nOutput: %s", err, stderr.String())
+	}
+	info := parseYtdlpInfo(stdout.Bytes())
+
+	// Find the downloaded file
+	var matches []string
+	if _, err := os.Stat(info.FilePath); info.FilePath != "" && err == nil {
+		matches = []string{info.FilePath}
+	} else if job.MediaType == "audio" {
+		ext := "." + getAudioFormat(job.AudioFormat).ext
+		// For audio, look for the file with title name
+		if !strings.HasPrefix(title, "audio_") {
+			// Try exact match first
+			pattern := filepath.Join(d.config.TmpfsPath, title+ext)
+			if _, err := os.Stat(pattern); err == nil {
+				matches = []string{pattern}
+			}
+		}
+		// If not found, find most recently created audio file
+		if len(matches) == 0 {
+			if mostRecent := d.mostRecentFile("*" + ext); mostRecent != "" {
+				matches = []string{mostRecent}
+				// If title was not extracted before, try to extract it now and rename file
+				if strings.HasPrefix(title, "audio_") {
+					if newTitle := d.fetchTitle(ctx, job.URL, platformArgs()); newTitle != "" {
+						newPath := filepath.Join(d.config.TmpfsPath, newTitle+ext)
+						if err := os.Rename(mostRecent, newPath); err == nil {
+							matches = []string{newPath}
+						}
+					}
+				}
+			}
+		}
+	} else {
+		// For video, look for any file with jobID prefix except side files
+		pattern := filepath.Join(d.config.TmpfsPath, job.ID+"*")
+		all, _ := filepath.Glob(pattern)
+		for _, match := range all {
+			switch filepath.Ext(match) {
+			case ".srt", ".vtt", ".jpg", ".webp", ".part":
+				continue
+			}
+			matches = append(matches, match)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("downloaded file not found")
+	}
+
+	media := &Media{
+		Path:      matches[0],
+		Title:     info.Title,
+		Performer: info.performer(),
+		Album:     info.Album,
+		Duration:  info.Duration,
+	}
+	if job.SponsorBlock {
+		// yt-dlp already reports the duration after the cut
+		media.Removed = info.sponsorSeconds()
+	}
+
+	if job.MediaType == "video" && job.SubMode != "" {
+		subs, _ := filepath.Glob(filepath.Join(d.config.TmpfsPath, job.ID+".*.srt"))
+		if len(subs) > 0 {
+			media.SubsPath = subs[0]
+		}
+		for _, extra := range subs[min(1, len(subs)):] {
+			media.addTempFile(extra)
+		}
+	}
+
+	if job.MediaType == "audio" && job.Chapters {
+		d.collectChapters(ctx, job, info, media)
+	}
+
+	if job.MediaType == "audio" && job.EmbedTags {
+		cover := strings.TrimSuffix(media.Path, filepath.Ext(media.Path)) + ".jpg"
+		if _, err := os.Stat(cover); err == nil {
+			media.addTempFile(cover)
+			if thumb, err := makeTelegramThumb(ctx, cover); err == nil {
+				media.ThumbPath = thumb
+			} else {
+				log.Printf("Thumbnail conversion failed: %v", err)
+			}
+		}
+	}
+
+	return media, nil
+}
+
+// ytdlpInfoTemplate makes yt-dlp print the fields of ytdlpInfo as JSON
+const ytdlpInfoTemplate = "%(.{title,artist,creator,uploader,album,duration,filepath,chapters,sponsorblock_chapters})j"
+
+// sponsorBlockCategories are the segment categories cut from videos
+var sponsorBlockCategories = []string{"sponsor", "intro", "selfpromo"}
+
+// ytdlpInfo is the metadata yt-dlp prints after download
+type ytdlpInfo struct {
+	Title    string  `json:"title"`
+	Artist   string  `json:"artist"`
+	Creator  string  `json:"creator"`
+	Uploader string  `json:"uploader"`
+	Album    string  `json:"album"`
+	Duration float64 `json:"duration"`
+	FilePath string  `json:"filepath"`
+
+	Chapters []struct {
+		StartTime float64 `json:"start_time"`
+		EndTime   float64 `json:"end_time"`
+		Title     string  `json:"title"`
+	} `json:"chapters"`
+
+	SponsorBlockChapters []struct {
+		StartTime float64 `json:"start_time"`
+		EndTime   float64 `json:"end_time"`
+		Category  string  `json:"category"`
+	} `json:"sponsorblock_chapters"`
+}
+
+// sponsorSeconds returns the total length of removed SponsorBlock segments.
+// Overlapping segments are counted once.
+func (i *ytdlpInfo) sponsorSeconds() float64 {
+	type span struct{ start, end float64 }
+	var spans []span
+	for _, ch := range i.SponsorBlockChapters {
+		for _, category := range sponsorBlockCategories {
+			if ch.Category == category && ch.EndTime > ch.StartTime {
+				spans = append(spans, span{ch.StartTime, ch.EndTime})
+			}
+		}
+	}
+	sort.Slice(spans, func(a, b int) bool { return spans[a].start < spans[b].start })
+
+	var total, covered float64
+	for _, s := range spans {
+		if s.start < covered {
+			s.start = covered
+		}
+		if s.end > s.start {
+			total += s.end - s.start
+		}
+		if s.end > covered {
+			covered = s.end
+		}
+	}
+	return total
+}
+
+// performer returns the best guess of the track artist
+func (i *ytdlpInfo) performer() string {
+	for _, name := range []string{i.Artist, i.Creator, i.Uploader} {
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// parseYtdlpInfo parses the last JSON line of yt-dlp output.
+// Returns empty info if nothing could be parsed.
+func parseYtdlpInfo(output []byte) *ytdlpInfo {
+	var info ytdlpInfo
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if err := json.Unmarshal([]byte(lines[i]), &info); err == nil {
+			break
+		}
+	}
+	return &info
+}
+
+// collectChapters picks up the per-chapter files yt-dlp produced and
+// tags them with chapter titles and track numbers
+func (d *ytdlpDownloader) collectChapters(ctx context.Context, job *queue.Job, info *ytdlpInfo, media *Media) {
+	ext := "." + getAudioFormat(job.AudioFormat).ext
+	files, _ := filepath.Glob(filepath.Join(d.config.TmpfsPath, job.ID+"_ch*"+ext))
+	sort.Strings(files) // Zero-padded section numbers keep the order
+
+	for i, file := range files {
+		chapter := &Media{
+			Path:      file,
+			Title:     fmt.Sprintf("%02d. %s", i+1, media.Title),
+			Performer: media.Performer,
+			Album:     media.Title,
+		}
+		if i < len(info.Chapters) {
+			ch := info.Chapters[i]
+			if ch.Title != "" {
+				chapter.Title = ch.Title
+			}
+			chapter.Duration = ch.EndTime - ch.StartTime
+		}
+
+		tags := map[string]string{
+			"title":  chapter.Title,
+			"artist": chapter.Performer,
+			"album":  chapter.Album,
+			"track":  fmt.Sprintf("%d/%d", i+1, len(files)),
+		}
+		if err := tagAudio(ctx, file, tags); err != nil {
+			log.Printf("Chapter tagging failed for %s: %v", file, err)
+		}
+
+		media.Chapters = append(media.Chapters, chapter)
+	}
+}
+
+// fetchTitle asks yt-dlp for the media title without downloading it.
+// Returns a filesystem-safe title or empty string on failure.
+func (d *ytdlpDownloader) fetchTitle(ctx context.Context, url string, platformArgs []string) string {
+	args := append([]string{"--no-cache-dir", "--print", "%(title)s"}, platformArgs...)
+	args = append(args, url)
+
+	titleBytes, err := exec.CommandContext(ctx, "yt-dlp", args...).Output()
+	if err != nil || len(titleBytes) == 0 {
+		return ""
+	}
+
+	title := sanitizeFilename(strings.TrimSpace(string(titleBytes)))
+	// Limit to reasonable length (220 chars) to avoid filesystem limits
+	if len(title) > 220 {
+		title = title[:220]
+	}
+	return title
+}
+
+// createTempCookies copies the cookies file into tmpfs.
+// Returns the original path if the copy fails.
+func (d *ytdlpDownloader) createTempCookies(jobID, originalFile string) string {
+	tempCookiesFile := filepath.Join(d.config.TmpfsPath, fmt.Sprintf("cookies_%s_%d.txt", jobID, time.Now().UnixNano()))
+	if data, err := os.ReadFile(originalFile); err == nil {
+		if err := os.WriteFile(tempCookiesFile, data, 0644); err == nil {
+			os.Chmod(tempCookiesFile, 0644)
+			return tempCookiesFile
+		}
+	}
+	return originalFile // Fallback to original
+}
+
+// mostRecentFile returns the most recently modified file in tmpfs matching the pattern
+func (d *ytdlpDownloader) mostRecentFile(pattern string) string {
+	allMatches, _ := filepath.Glob(filepath.Join(d.config.TmpfsPath, pattern))
+
+	var mostRecent string
+	var mostRecentTime time.Time
+	for _, match := range allMatches {
+		if stat, err := os.Stat(match); err == nil {
+			if stat.ModTime().After(mostRecentTime) {
+				mostRecentTime = stat.ModTime()
+				mostRecent = match
+			}
+		}
+	}
+	return mostRecent
+}
+
+// sanitizeFilename removes or replaces characters that are invalid in filenames
+func sanitizeFilename(name string) string {
+	// Replace invalid filesystem characters
+	invalidChars := []string{"/", "\\", ":", "*", "?", "\"", "<", ">", "|", "\x00"}
+	result := name
+	for _, char := range invalidChars {
+		result = strings.ReplaceAll(result, char, "_")
+	}
+	// Remove leading/trailing spaces and dots
+	result = strings.Trim(result, " .")
+	// Replace multiple spaces/underscores with single underscore
+	result = strings.ReplaceAll(result, "  ", " ")
+	result = strings.ReplaceAll(result, "__", "_")
+	return result
+}
+
+func getFormatForQuality(quality string) string {
+	switch quality {
+	case "1080p":
+		return "bestvideo[height<=1080][ext=mp4]+bestaudio[ext=m4a]/best[height<=1080][ext=mp4]/best"
+	case "720p":
+		return "bestvideo[height<=720][ext=mp4]+bestaudio[ext=m4a]/best[height<=720][ext=mp4]/best"
+	case "480p":
+		return "bestvideo[height<=480][ext=mp4]+bestaudio[ext=m4a]/best[height<=480][ext=mp4]/best"
+	case "360p":
+		return "bestvideo[height<=360][ext=mp4]+bestaudio[ext=m4a]/best[height<=360][ext=mp4]/best"
+	case "audio":
+		return "bestaudio[ext=m4a]/bestaudio/best"
+	case "gif":
+		// Audio is stripped, small video is enough
+		return "bestvideo[height<=720][ext=mp4]/best[height<=720][ext=mp4]/best[height<=720]/best"
+	default: // "best"
+		return "bestvideo[ext=mp4]+bestaudio[ext=m4a]/best[ext=mp4]/best"
+	}
+}
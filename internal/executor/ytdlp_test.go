@@ -0,0 +1,279 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"envedour-bot/internal/config"
+	"envedour-bot/internal/queue"
+)
+
+// fakeYtdlpEnv makes the test binary act as yt-dlp, see fakeYtdlp
+const fakeYtdlpEnv = "FAKE_YTDLP"
+
+// fakeYtdlpArgsEnv names a file the fake yt-dlp writes its arguments to
+const fakeYtdlpArgsEnv = "FAKE_YTDLP_ARGS"
+
+// fakeVideoDuration is the length of the video before the cut
+const fakeVideoDuration = 600
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeYtdlpEnv) == "1" {
+		if err := fakeYtdlp(os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeYtdlp does what yt-dlp does with --sponsorblock-remove: it looks the
+// video up in the SponsorBlock API, "downloads" it without the segments and
+// prints the --print template fields, with the duration after the cut
+func fakeYtdlp(args []string) error {
+	if argsFile := os.Getenv(fakeYtdlpArgsEnv); argsFile != "" {
+		if err := os.WriteFile(argsFile, []byte(strings.Join(args, "\n")), 0o644); err != nil {
+			return err
+		}
+	}
+
+	var output, api, categories string
+	for i := 0; i < len(args)-1; i++ {
+		switch args[i] {
+		case "-o":
+			if output == "" {
+				output = args[i+1]
+			}
+		case "--sponsorblock-api":
+			api = args[i+1]
+		case "--sponsorblock-remove":
+			categories = args[i+1]
+		}
+	}
+	link, err := url.Parse(args[len(args)-1])
+	if err != nil {
+		return err
+	}
+	videoID := link.Query().Get("v")
+
+	type chapter struct {
+		StartTime float64 `json:"start_time"`
+		EndTime   float64 `json:"end_time"`
+		Category  string  `json:"category"`
+	}
+	var chapters []chapter
+	removed := 0.0
+	if categories != "" {
+		hash := sha256.Sum256([]byte(videoID))
+		categoriesJSON, _ := json.Marshal(strings.Split(categories, ","))
+		query := url.Values{"service": {"YouTube"}, "categories": {string(categoriesJSON)}}
+		resp, err := http.Get(fmt.Sprintf("%s/api/skipSegments/%s?%s", api, hex.EncodeToString(hash[:])[:4], query.Encode()))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		var videos []struct {
+			VideoID  string `json:"videoID"`
+			Segments []struct {
+				Segment  [2]float64 `json:"segment"`
+				Category string     `json:"category"`
+			} `json:"segments"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&videos); err != nil {
+			return err
+		}
+		for _, video := range videos {
+			if video.VideoID != videoID {
+				continue
+			}
+			for _, s := range video.Segments {
+				chapters = append(chapters, chapter{s.Segment[0], s.Segment[1], s.Category})
+				removed += s.Segment[1] - s.Segment[0]
+			}
+		}
+	}
+
+	path := strings.ReplaceAll(output, "%(ext)s", "mp4")
+	if err := os.WriteFile(path, []byte("video"), 0o644); err != nil {
+		return err
+	}
+	return json.NewEncoder(os.Stdout).Encode(map[string]any{
+		"title":                 "Test video",
+		"duration":              fakeVideoDuration - removed,
+		"filepath":              path,
+		"sponsorblock_chapters": chapters,
+	})
+}
+
+// useFakeYtdlp puts the test binary on PATH as yt-dlp
+func useFakeYtdlp(t *testing.T) {
+	t.Helper()
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := t.TempDir()
+	if err := os.Symlink(self, filepath.Join(bin, "yt-dlp")); err != nil {
+		t.Skipf("can't link fake yt-dlp: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv(fakeYtdlpEnv, "1")
+}
+
+func TestDownloadSponsorBlock(t *testing.T) {
+	useFakeYtdlp(t)
+
+	const videoID = "dQw4w9WgXcQ"
+	var (
+		mu        sync.Mutex
+		requested []url.Values
+		paths     []string
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Query())
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		fmt.Fprintf(w, `[
+			{"videoID": "other", "segments": [{"segment": [0, 300], "category": "sponsor"}]},
+			{"videoID": %q, "segments": [
+				{"segment": [0, 5.5], "category": "intro"},
+				{"segment": [120, 150], "category": "sponsor"},
+				{"segment": [590, 600], "category": "selfpromo"}
+			]}
+		]`, videoID)
+	}))
+	defer api.Close()
+
+	cfg := &config.Config{TmpfsPath: t.TempDir(), SponsorBlockAPI: api.URL}
+	job := &queue.Job{
+		ID:           "job1",
+		URL:          "https://www.youtube.com/watch?v=" + videoID,
+		Quality:      "720p",
+		MediaType:    "video",
+		SponsorBlock: true,
+	}
+	media, err := newYtdlpDownloader(cfg, false).Download(context.Background(), job)
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+
+	if len(requested) != 1 {
+		t.Fatalf("SponsorBlock API called %d times, want 1", len(requested))
+	}
+	hash := sha256.Sum256([]byte(videoID))
+	if want := "/api/skipSegments/" + hex.EncodeToString(hash[:])[:4]; paths[0] != want {
+		t.Errorf("requested %s, want %s", paths[0], want)
+	}
+	var categories []string
+	if err := json.Unmarshal([]byte(requested[0].Get("categories")), &categories); err != nil {
+		t.Fatalf("bad categories %q: %v", requested[0].Get("categories"), err)
+	}
+	if !reflect.DeepEqual(categories, sponsorBlockCategories) {
+		t.Errorf("requested categories %v, want %v", categories, sponsorBlockCategories)
+	}
+
+	if media.Removed != 45.5 {
+		t.Errorf("Removed = %v, want 45.5", media.Removed)
+	}
+	if want := fakeVideoDuration - 45.5; media.Duration != want {
+		t.Errorf("Duration = %v, want %v", media.Duration, want)
+	}
+}
+
+func TestDownloadWithoutSponsorBlock(t *testing.T) {
+	useFakeYtdlp(t)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("SponsorBlock API called without SponsorBlock: %s", r.URL)
+	}))
+	defer api.Close()
+
+	cfg := &config.Config{TmpfsPath: t.TempDir(), SponsorBlockAPI: api.URL}
+	job := &queue.Job{
+		ID:        "job2",
+		URL:       "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+		Quality:   "720p",
+		MediaType: "video",
+	}
+	media, err := newYtdlpDownloader(cfg, false).Download(context.Background(), job)
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if media.Removed != 0 || media.Duration != fakeVideoDuration {
+		t.Errorf("Removed = %v, Duration = %v, want 0 and %d", media.Removed, media.Duration, fakeVideoDuration)
+	}
+}
+
+func TestDownloadSections(t *testing.T) {
+	useFakeYtdlp(t)
+	argsFile := filepath.Join(t.TempDir(), "args")
+	t.Setenv(fakeYtdlpArgsEnv, argsFile)
+
+	for _, tc := range []struct {
+		name      string
+		job       queue.Job
+		want      string // --download-sections value, "" if the flag isn't expected
+		keyframes bool
+	}{
+		{"clip", queue.Job{MediaType: "video", ClipStart: 90, ClipEnd: 150}, "*90-150", true},
+		{"open end", queue.Job{MediaType: "video", ClipStart: 90.5}, "*90.5-inf", true},
+		{"animation", queue.Job{MediaType: "animation", Quality: "gif"}, "*0-10", false},
+		{"whole video", queue.Job{MediaType: "video"}, "", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{TmpfsPath: t.TempDir(), AnimationMaxSeconds: 10}
+			job := tc.job
+			job.ID = "sections"
+			job.URL = "https://www.youtube.com/watch?v=dQw4w9WgXcQ"
+			if job.Quality == "" {
+				job.Quality = "720p"
+			}
+			if _, err := newYtdlpDownloader(cfg, false).Download(context.Background(), &job); err != nil {
+				t.Fatalf("Download: %v", err)
+			}
+
+			data, err := os.ReadFile(argsFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			args := strings.Split(string(data), "\n")
+			var sections []string
+			keyframes := false
+			for i, arg := range args {
+				switch arg {
+				case "--download-sections":
+					if i+1 < len(args) {
+						sections = append(sections, args[i+1])
+					}
+				case "--force-keyframes-at-cuts":
+					keyframes = true
+				}
+			}
+
+			if tc.want == "" {
+				if len(sections) != 0 {
+					t.Errorf("--download-sections %v, want none", sections)
+				}
+			} else if len(sections) != 1 || sections[0] != tc.want {
+				t.Errorf("--download-sections %v, want %q", sections, tc.want)
+			}
+			if keyframes != tc.keyframes {
+				t.Errorf("--force-keyframes-at-cuts passed: %v, want %v", keyframes, tc.keyframes)
+			}
+		})
+	}
+}
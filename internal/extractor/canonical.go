@@ -0,0 +1,95 @@
+package extractor
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// shortLinkTimeout bounds resolving a short link
+const shortLinkTimeout = 5 * time.Second
+
+// shortLinkHosts redirect to the real page and are resolved before download
+var shortLinkHosts = map[string]bool{
+	"vt.tiktok.com": true,
+	"vm.tiktok.com": true,
+	"t.co":          true,
+	"bit.ly":        true,
+	"goo.gl":        true,
+	"tinyurl.com":   true,
+	"clck.ru":       true,
+}
+
+// trackingParams are query parameters that only identify who shared the link
+var trackingParams = map[string]bool{
+	"si":      true,
+	"feature": true,
+	"pp":      true,
+	"fbclid":  true,
+	"gclid":   true,
+	"yclid":   true,
+	"igshid":  true,
+	"igsh":    true,
+	"ref":     true,
+	"ref_src": true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_t":      true,
+	"_r":      true,
+}
+
+var shortLinkClient = &http.Client{Timeout: shortLinkTimeout}
+
+// Canonicalize turns the different forms of a link to the same media into
+// one stable URL: short links are resolved, tracking parameters dropped and
+// platform-specific forms (youtu.be, shorts, mobile hosts) rewritten.
+// The result is used as the job URL and therefore as the cache key.
+// Links that can't be parsed are returned unchanged.
+func Canonicalize(ctx context.Context, rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	if shortLinkHosts[strings.ToLower(u.Hostname())] {
+		if resolved := resolveShortLink(ctx, u.String()); resolved != nil {
+			u = resolved
+		}
+	}
+
+	u.Host = strings.TrimSuffix(strings.ToLower(u.Host), ".")
+	u.Fragment = ""
+	stripTracking(u)
+	ForURL(u.String()).Canonicalize(u)
+	return u.String()
+}
+
+// resolveShortLink follows redirects with a HEAD request and returns the
+// final URL, or nil if the link couldn't be resolved in time
+func resolveShortLink(ctx context.Context, rawURL string) *url.URL {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", desktopUserAgent)
+
+	resp, err := shortLinkClient.Do(req)
+	if err != nil {
+		return nil
+	}
+	resp.Body.Close()
+	return resp.Request.URL
+}
+
+// stripTracking removes utm_* and other share tracking parameters
+func stripTracking(u *url.URL) {
+	query := u.Query()
+	for name := range query {
+		if trackingParams[name] || strings.HasPrefix(name, "utm_") {
+			query.Del(name)
+		}
+	}
+	u.RawQuery = query.Encode()
+}
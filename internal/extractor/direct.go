@@ -0,0 +1,25 @@
+package extractor
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// directMediaTypes maps file extensions served as-is to the media type
+// they are delivered as
+var directMediaTypes = map[string]string{
+	".mp4":  "video",
+	".webm": "video",
+	".mp3":  "audio",
+}
+
+// DirectMediaType returns "video" or "audio" when the URL points straight
+// at a media file, or empty string when it needs a site extractor
+func DirectMediaType(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return directMediaTypes[strings.ToLower(path.Ext(u.Path))]
+}
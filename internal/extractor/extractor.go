@@ -0,0 +1,111 @@
+package extractor
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"envedour-bot/internal/config"
+)
+
+// Extractor describes how media from a single platform is fetched.
+// Adding support for a new site means implementing this interface and
+// calling Register from the package's init.
+type Extractor interface {
+	// Name is a short identifier used in logs ("tiktok", "youtube", ...)
+	Name() string
+	// Hosts lists the domains served by this extractor. Subdomains match too.
+	Hosts() []string
+	// Args returns extra yt-dlp arguments required by the platform
+	Args() []string
+	// Headers returns extra HTTP headers in "Name:Value" form
+	Headers() []string
+	// CookiesFile returns the platform-specific cookies path from the config
+	CookiesFile(cfg *config.Config) string
+	// RequiresCookies reports whether the platform usually rejects
+	// anonymous requests
+	RequiresCookies() bool
+	// DefaultMediaType is "video" or "audio"
+	DefaultMediaType() string
+	// SponsorBlock reports whether sponsor segments can be cut via SponsorBlock
+	SponsorBlock() bool
+	// AutoDownload reports whether links are downloaded in best quality
+	// right away instead of showing the quality selection keyboard
+	AutoDownload() bool
+	// Canonicalize rewrites the link in place into the platform's
+	// canonical form, see the package-level Canonicalize
+	Canonicalize(u *url.URL)
+}
+
+var (
+	mu         sync.RWMutex
+	extractors []Extractor
+	fallback   Extractor = genericExtractor{}
+)
+
+// Register adds an extractor to the registry. Extractors registered later
+// take precedence when several of them claim the same host.
+func Register(e Extractor) {
+	mu.Lock()
+	defer mu.Unlock()
+	extractors = append([]Extractor{e}, extractors...)
+}
+
+// ForURL returns the extractor responsible for the given URL.
+// Unknown hosts and unparsable URLs get the generic extractor.
+func ForURL(rawURL string) Extractor {
+	host := hostOf(rawURL)
+	if host == "" {
+		return fallback
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, e := range extractors {
+		for _, h := range e.Hosts() {
+			if host == h || strings.HasSuffix(host, "."+h) {
+				return e
+			}
+		}
+	}
+	return fallback
+}
+
+// YtdlpArgs returns the platform arguments together with its headers,
+// ready to be appended to a yt-dlp command line
+func YtdlpArgs(e Extractor) []string {
+	args := append([]string{}, e.Args()...)
+	for _, h := range e.Headers() {
+		args = append(args, "--add-header", h)
+	}
+	return args
+}
+
+// CookiesFile returns the cookies file to use for the extractor.
+// Falls back to the deprecated COOKIES_FILE and returns empty string
+// if nothing usable is configured.
+func CookiesFile(e Extractor, cfg *config.Config) string {
+	if path := e.CookiesFile(cfg); path != "" {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	// Fallback to deprecated COOKIES_FILE for backward compatibility
+	if cfg.CookiesFile != "" {
+		if _, err := os.Stat(cfg.CookiesFile); err == nil {
+			return cfg.CookiesFile
+		}
+	}
+
+	return ""
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
@@ -0,0 +1,124 @@
+package extractor
+
+import (
+	"net/url"
+	"strings"
+
+	"envedour-bot/internal/config"
+)
+
+const desktopUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
+
+func init() {
+	Register(youtubeExtractor{})
+	Register(instagramExtractor{})
+	Register(tiktokExtractor{})
+}
+
+// genericExtractor handles every host without a dedicated extractor
+type genericExtractor struct{}
+
+func (genericExtractor) Name() string                      { return "generic" }
+func (genericExtractor) Hosts() []string                   { return nil }
+func (genericExtractor) Args() []string                    { return []string{"--legacy-server-connect"} }
+func (genericExtractor) Headers() []string                 { return nil }
+func (genericExtractor) CookiesFile(*config.Config) string { return "" }
+func (genericExtractor) RequiresCookies() bool             { return false }
+func (genericExtractor) DefaultMediaType() string          { return "video" }
+func (genericExtractor) SponsorBlock() bool                { return false }
+func (genericExtractor) AutoDownload() bool                { return false }
+func (genericExtractor) Canonicalize(*url.URL)             {}
+
+type youtubeExtractor struct{ genericExtractor }
+
+func (youtubeExtractor) Name() string { return "youtube" }
+func (youtubeExtractor) Hosts() []string {
+	return []string{"youtube.com", "youtu.be", "youtube-nocookie.com"}
+}
+func (youtubeExtractor) CookiesFile(cfg *config.Config) string { return cfg.YouTubeCookies }
+func (youtubeExtractor) SponsorBlock() bool                    { return true }
+
+// Canonicalize turns youtu.be, shorts, live, embed and mobile links into
+// www.youtube.com/watch?v=ID, keeping only the video ID
+func (youtubeExtractor) Canonicalize(u *url.URL) {
+	id := u.Query().Get("v")
+	path := strings.Trim(u.Path, "/")
+	switch {
+	case u.Host == "youtu.be":
+		id = path
+	case strings.HasPrefix(path, "shorts/"), strings.HasPrefix(path, "live/"), strings.HasPrefix(path, "embed/"):
+		id = path[strings.Index(path, "/")+1:]
+	}
+	if id == "" || strings.Contains(id, "/") {
+		return
+	}
+	u.Scheme = "https"
+	u.Host = "www.youtube.com"
+	u.Path = "/watch"
+	u.RawQuery = url.Values{"v": {id}}.Encode()
+}
+
+// instagramExtractor downloads reels and posts right away, they are
+// always short videos
+type instagramExtractor struct{ genericExtractor }
+
+func (instagramExtractor) Name() string                          { return "instagram" }
+func (instagramExtractor) Hosts() []string                       { return []string{"instagram.com", "instagr.am"} }
+func (instagramExtractor) CookiesFile(cfg *config.Config) string { return cfg.InstagramCookies }
+func (instagramExtractor) RequiresCookies() bool                 { return true }
+func (instagramExtractor) AutoDownload() bool                    { return true }
+
+// Canonicalize drops the query, which only carries share tracking
+func (instagramExtractor) Canonicalize(u *url.URL) {
+	u.Scheme = "https"
+	u.Host = "www.instagram.com"
+	u.Path = strings.Replace(u.Path, "/reels/", "/reel/", 1)
+	u.RawQuery = ""
+}
+
+// tiktokExtractor needs browser-like headers to get past 403 errors
+type tiktokExtractor struct{ genericExtractor }
+
+func (tiktokExtractor) Name() string                          { return "tiktok" }
+func (tiktokExtractor) Hosts() []string                       { return []string{"tiktok.com"} }
+func (tiktokExtractor) CookiesFile(cfg *config.Config) string { return cfg.TikTokCookies }
+func (tiktokExtractor) RequiresCookies() bool                 { return true }
+func (tiktokExtractor) AutoDownload() bool                    { return true }
+
+// Canonicalize drops the query, which only carries share tracking.
+// Short links are resolved before this point.
+func (tiktokExtractor) Canonicalize(u *url.URL) {
+	u.Scheme = "https"
+	if u.Host == "m.tiktok.com" {
+		u.Host = "www.tiktok.com"
+	}
+	u.RawQuery = ""
+}
+
+func (tiktokExtractor) Args() []string {
+	return []string{
+		"--no-check-certificate",
+		"-4", // Force IPv4
+		"--legacy-server-connect",
+		"--user-agent", desktopUserAgent,
+		"--referer", "https://www.tiktok.com/",
+	}
+}
+
+func (tiktokExtractor) Headers() []string {
+	return []string{
+		"Accept:text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8",
+		"Accept-Language:en-US,en;q=0.9",
+		"Accept-Encoding:gzip, deflate, br",
+		"DNT:1",
+		"Connection:keep-alive",
+		"Upgrade-Insecure-Requests:1",
+		"Sec-Fetch-Dest:document",
+		"Sec-Fetch-Mode:navigate",
+		"Sec-Fetch-Site:none",
+		"Sec-Fetch-User:?1",
+		"sec-ch-ua:\"Google Chrome\";v=\"131\", \"Chromium\";v=\"131\", \"Not_A Brand\";v=\"24\"",
+		"sec-ch-ua-mobile:?0",
+		"sec-ch-ua-platform:\"Windows\"",
+	}
+}
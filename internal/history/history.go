@@ -0,0 +1,105 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"envedour-bot/internal/queue"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// MaxEntries is how many recent downloads are kept per chat
+const MaxEntries = 50
+
+// entryTTL drops the history of chats that stopped using the bot
+const entryTTL = 90 * 24 * time.Hour
+
+// Entry is a completed download
+type Entry struct {
+	Job   *queue.Job        `json:"job"`
+	Title string            `json:"title"`
+	File  *queue.CachedFile `json:"file,omitempty"` // nil when the result was several files
+	Date  time.Time         `json:"date"`
+}
+
+// Store keeps the recent downloads of each chat in a capped Redis list,
+// newest first
+type Store struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+// New creates a history store. client may be nil, then nothing is recorded.
+func New(client *redis.Client) *Store {
+	return &Store{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+func historyKey(chatID int64) string {
+	return fmt.Sprintf("history:%d", chatID)
+}
+
+// Add records a completed download
+func (s *Store) Add(chatID int64, entry *Entry) error {
+	if s.client == nil {
+		return fmt.Errorf("history store is not available")
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+
+	key := historyKey(chatID)
+	pipe := s.client.TxPipeline()
+	pipe.LPush(s.ctx, key, data)
+	pipe.LTrim(s.ctx, key, 0, MaxEntries-1)
+	pipe.Expire(s.ctx, key, entryTTL)
+	_, err = pipe.Exec(s.ctx)
+	return err
+}
+
+// List returns up to limit entries starting at offset, newest first,
+// together with the total number of entries
+func (s *Store) List(chatID int64, offset, limit int) ([]*Entry, int, error) {
+	if s.client == nil {
+		return nil, 0, nil
+	}
+	key := historyKey(chatID)
+	total, err := s.client.LLen(s.ctx, key).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	values, err := s.client.LRange(s.ctx, key, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]*Entry, 0, len(values))
+	for _, v := range values {
+		var entry Entry
+		if err := json.Unmarshal([]byte(v), &entry); err != nil || entry.Job == nil {
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, int(total), nil
+}
+
+// Get returns the entry of the job, or nil if it's no longer in the history
+func (s *Store) Get(chatID int64, jobID string) (*Entry, error) {
+	entries, _, err := s.List(chatID, 0, MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Job.ID == jobID {
+			return entry, nil
+		}
+	}
+	return nil, nil
+}
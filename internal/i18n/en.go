@@ -0,0 +1,282 @@
+package i18n
+
+var en = map[string]string{
+	// Downloads
+	"exec.overheated":          "The server is overheated. Please try again later.",
+	"exec.low_memory":          "The server is out of memory. Please try again later.",
+	"exec.download_failed":     "❌ Download failed.\n\nPossible reasons:\n• Invalid link\n• The video is unavailable\n• Network problems\n• Not enough memory\n\nTry another link or try again later.",
+	"exec.sponsorblock_cut":    "✂️ SponsorBlock: cut %s (sponsors, intros, self-promotion)",
+	"exec.what_voice":          "a voice message",
+	"exec.what_video_note":     "a video message",
+	"exec.too_long":            "❌ Too long for %s: %d s (maximum %d s)\n\nAdd a fragment after the link, e.g.: link 0:00-0:%02d",
+	"exec.send_failed":         "❌ Failed to send %s.\n\nTry another link or try again later.",
+	"exec.gif_failed":          "❌ Failed to make a GIF.\n\nTry another link or try again later.",
+	"exec.chapters_failed":     "❌ Failed to send the chapters.\n\nThe files may be too large.\nTry downloading the whole audio.",
+	"exec.no_chapters":         "📑 This video has no chapters, sending the whole audio.",
+	"exec.audio_failed":        "❌ Failed to send the audio.\n\nThe file may be too large or corrupted.\nTry another link.",
+	"exec.video_failed":        "❌ Failed to send the video.",
+	"exec.video_failed_config": "The bot is misconfigured. Please contact the administrator.",
+	"exec.video_failed_file":   "The file may be too large or corrupted.\nTry another link.",
+	"exec.video_failed_retry":  "Please try again later.",
+	"exec.subs_not_found":      "💬 No subtitles in \"%s\", sending the video without them.",
+	"exec.subs_burn_failed":    "💬 Failed to burn in the subtitles, sending the video without them.",
+	"inline.send_failed":       "❌ Failed to send the file to the chat.",
+	"duration.min_sec":         "%d min %d s",
+	"duration.sec":             "%d s",
+
+	// Live streams
+	"live.offer":              "🔴 This is a live stream.\n\nRecord it from now on? Maximum: %d min.",
+	"live.button":             "⏺ %d min",
+	"live.cancel":             "❌ Cancel",
+	"live.unavailable":        "❌ This is a live stream, but recording is unavailable right now. Please try again later.",
+	"live.inline_unavailable": "🔴 Live streams can't be recorded in inline mode. Send the link to the bot in a private chat.",
+
+	// Configuration
+	"config.bot_token":             "BOT_TOKEN is required\n\nPlease set the BOT_TOKEN environment variable:\n  export BOT_TOKEN=\"your_token_here\"\n\nOr create a .env file with the following content:\n  BOT_TOKEN=your_token_here\n\nYou can get a token from @BotFather in Telegram:\n  1. Open https://t.me/BotFather\n  2. Send the /newbot command\n  3. Follow the instructions\n  4. Copy the token you get\n\nSee QUICKSTART.md or README.md for details",
+	"config.default_language":      "DEFAULT_LANGUAGE=%q is not supported\n\nSupported values: %s",
+	"config.access_mode":           "ACCESS_MODE=%q is not supported\n\nSupported values:\n  open      - the bot is available to everyone (default)\n  allowlist - only to listed users and chats\n  denylist  - to everyone except listed users and chats\n  invite    - by invite codes\n\nSee docs/CONFIGURATION.md for details",
+	"config.required_channel":      "REQUIRED_CHANNEL=%q is not supported\n\nUse the channel's name (@channel) or its numeric ID (-1001234567890)",
+	"config.required_channel_link": "REQUIRED_CHANNEL_LINK is required for REQUIRED_CHANNEL=%s\n\nA channel given by numeric ID needs a link for the subscribe button:\n  REQUIRED_CHANNEL_LINK=https://t.me/+invite_hash\n\nOr give the channel by name: REQUIRED_CHANNEL=@channel",
+	"config.error":                 "❌ Configuration error:\n%v\n\nCheck the settings and try again.",
+
+	// Keyboards
+	"kb.quality":          "⚙️ Quality",
+	"kb.media":            "🎵 Audio/Video",
+	"kb.audio":            "🎧 Audio format",
+	"kb.delivery":         "📨 Delivery",
+	"kb.subs":             "💬 Subtitles",
+	"kb.status":           "📊 Status",
+	"kb.language":         "🌐 Language / Язык",
+	"kb.back":             "◀️ Back",
+	"kb.subs_off":         "🚫 Off",
+	"kb.subs_file":        "📄 As file",
+	"kb.subs_burn":        "🔥 Burn in",
+	"kb.subs_auto_on":     "🤖 Auto subtitles: on",
+	"kb.subs_auto_off":    "🤖 Auto subtitles: off",
+	"kb.voice_on":         "🎤 Audio as voice: on",
+	"kb.voice_off":        "🎤 Audio as voice: off",
+	"kb.round_on":         "⭕ Video as round note: on",
+	"kb.round_off":        "⭕ Video as round note: off",
+	"kb.sponsorblock_on":  "⏭ SponsorBlock (YouTube): on",
+	"kb.sponsorblock_off": "⏭ SponsorBlock (YouTube): off",
+	"kb.tags_on":          "🏷 Tags and cover: on",
+	"kb.tags_off":         "🏷 Tags and cover: off",
+	"kb.group_auto_on":    "🤖 All links: on",
+	"kb.group_auto_off":   "🤖 All links: off",
+	"kb.best":             "🏆 Best",
+	"kb.audio_only":       "🎵 Audio",
+	"kb.video_only":       "🎬 Video",
+	"kb.bitrate_best":     "🏆 Max",
+	"kb.chapters":         "📑 Audio by chapters",
+	"kb.subscribe":        "📢 Subscribe",
+	"kb.check_again":      "🔄 Check again",
+	"kb.newer":            "◀️ Newer",
+	"kb.older":            "Older ▶️",
+
+	// Language selection
+	"lang.name":   "🇬🇧 English",
+	"lang.auto":   "🌐 Auto (Telegram language)",
+	"lang.choose": "🌐 Language: %s\n\nChoose the bot's language. \"Auto\" follows your Telegram language.",
+	"lang.set":    "✅ Language: %s",
+
+	// Commands and menus
+	"cmd.start":             "Hi! I'm a video downloader bot.\n\n📥 Send a video link to download it\n🔍 Or just type what to find (sc <query> searches SoundCloud)\n\nUse the buttons below to adjust the settings:",
+	"cmd.start_group":       "\n\n👥 In a group, mention me or reply to my message with a link. Only admins can change the settings.",
+	"cmd.help":              "📋 Help:\n\n💡 Just send a video link to download it!\n\nUse the buttons to set the quality and media type.\n\n⚙️ Quality - choose the video resolution\n🎵 Audio/Video - choose what to download\n📊 Status - see the queue and your settings\n📜 /history - recent downloads\n🌐 /lang - bot language\n\n💎 /donate - priority processing for Telegram Stars",
+	"cmd.use_buttons":       "Use the buttons to adjust the settings:",
+	"cmd.unknown":           "❌ Unknown command. Use /help",
+	"menu.main":             "Main menu:",
+	"menu.quality":          "⚙️ Choose the quality:\n\nCurrent: %s",
+	"menu.quality_set":      "✅ Quality set: %s (%s)",
+	"menu.media":            "🎵 Choose the type:\n\nCurrent: %s",
+	"menu.media_audio_set":  "✅ Mode set: audio downloads (%s)\n\nNow send a video link.",
+	"menu.media_video_set":  "✅ Mode set: video downloads\nQuality: %s",
+	"menu.audio":            "🎧 Choose the audio format and bitrate:\n\nCurrent: %s",
+	"menu.audio_set":        "✅ Audio: %s",
+	"menu.delivery":         "📨 How to send files:\n\n🎤 Voice — audio up to %d s as OGG/Opus\n⭕ Round note — square video up to %d s",
+	"menu.subs":             "💬 Subtitles: %s\n\n📄 As file — .srt as a separate document\n🔥 Burn in — subtitles drawn over the video (slower)",
+	"menu.group_auto":       "Main menu:\n\n🤖 All links: the bot downloads every link in the group, not only those with a mention",
+	"menu.sponsorblock_on":  "✅ SponsorBlock on: sponsors, intros and self-promotion will be cut from YouTube videos",
+	"menu.sponsorblock_off": "✅ SponsorBlock off",
+	"media.video":           "video",
+	"media.audio":           "audio",
+	"status":                "📊 Queue: %d jobs\n\n⚙️ Current settings:\nQuality: %s\nType: %s\nAudio: %s\nSubtitles: %s",
+	"prefs.unavailable":     "❌ Settings are unavailable",
+	"prefs.save_failed":     "❌ Failed to save the settings",
+	"prefs.bitrate_best":    "max quality",
+	"prefs.subs_file":       "as file, %s",
+	"prefs.subs_burn":       "burned in, %s",
+	"prefs.subs_off":        "off",
+	"group.admins_only":     "⛔ Only admins can change the group's settings.",
+
+	// Links and queue
+	"url.no_links":              "❌ No video links found in the message.",
+	"url.bad_range":             "❌ Invalid fragment. Example: link 1:20-2:05",
+	"url.save_failed":           "❌ Failed to process the link. Please try again later.",
+	"url.choose_quality":        "📥 Choose the download quality:",
+	"url.fragment":              "\n✂️ Fragment: %s",
+	"url.queued_many":           "📥 Queued: %d of %d links.\nFiles will use your default settings.",
+	"url.skipped":               "\n\n⚠️ At most %d links are handled at once, the other %d were skipped.",
+	"clip.to_end":               "%s – end",
+	"queue.enqueue_failed":      "❌ Failed to queue the download. Please try again later.",
+	"queue.enqueue_many_failed": "❌ Failed to queue the downloads. Please try again later.",
+	"callback.bad_request":      "❌ Error: invalid request",
+	"callback.unavailable":      "❌ The service is unavailable. Try sending the link again.",
+	"callback.link_expired":     "❌ The link has expired or wasn't found. Please send it again.",
+	"callback.bad_link":         "❌ Invalid link",
+	"callback.request_expired":  "❌ The request has expired. Please send the link again.",
+	"live.too_long":             "❌ The maximum recording length is %d min.",
+	"live.recording":            "⏺ Recording the stream for %d min. The file will arrive when it's done.",
+
+	// Limits
+	"limit.check_failed":  "❌ Failed to check the limits. Please try again later.",
+	"limit.requests":      "⏳ Too many requests (limit: %d per minute).\nTry again in %s.",
+	"limit.jobs":          "📊 Daily download limit reached (%d).\nResets in %s.",
+	"limit.bytes":         "📊 Daily traffic limit reached (%d MB).\nResets in %s.",
+	"limit.donors_higher": "\n\n💎 Donors have higher limits.",
+	"wait.hours":          "%d h %d min",
+	"wait.minutes":        "%d min",
+
+	// Search and inline mode
+	"search.too_short":         "Please send a video link or a search query.",
+	"search.unavailable":       "❌ Search is unavailable right now. Send a video link.",
+	"search.searching":         "🔍 Searching: %s",
+	"search.failed":            "❌ Search failed. Please try again later.",
+	"search.nothing":           "🤷 Nothing found for: %s",
+	"search.results":           "🔍 Results for: %s\n\nChoose what to download:",
+	"search.retry_unavailable": "❌ The service is unavailable. Try searching again.",
+	"search.expired":           "❌ The search results have expired. Please search again.",
+	"search.queued":            "📥 Queued: %s",
+	"inline.unavailable":       "Inline mode is unavailable, open the bot",
+	"inline.subscribe":         "Subscribe to the channel to download",
+	"inline.download":          "📥 Download",
+	"inline.hint":              "Enter a link or a query",
+	"inline.loading":           "⏳ Loading: %s",
+	"inline.open":              "🔗 Open",
+	"inline.expired":           "❌ The request has expired. Please try again.",
+
+	// Payments
+	"pay.unavailable":         "💎 Priority access can't be bought right now. Please contact the bot's administrator.",
+	"pay.private_only":        "💎 Priority access can be bought in a private chat with the bot.",
+	"pay.plans":               "💎 Priority access\n\n• Your downloads are processed first\n• Higher limits\n• Longer live stream recordings\n\nPaid with Telegram Stars ⭐",
+	"pay.active":              "\n\nPriority is active %s, a purchase extends it.",
+	"pay.plan_button":         "%d days — %d ⭐",
+	"pay.plan_gone":           "❌ This plan is no longer available. Use /donate",
+	"pay.invoice_title":       "Priority for %d days",
+	"pay.invoice_description": "Downloads are processed first, limits are higher.",
+	"pay.invoice_label":       "%d days",
+	"pay.invoice_failed":      "❌ Failed to create the invoice. Please try again later.",
+	"pay.plan_changed":        "The plan has changed. Please request a new invoice with /donate.",
+	"pay.plan_missing":        "⚠️ Payment received, but the plan wasn't found. Please contact the administrator.",
+	"pay.grant_failed":        "⚠️ Payment received, but priority couldn't be enabled. Please contact the administrator.",
+	"pay.thanks":              "💎 Thank you for your support! Priority is active %s.",
+	"donor.forever":           "forever",
+	"donor.until":             "until %s",
+
+	// History
+	"history.load_failed":   "❌ Failed to load the history. Please try again later.",
+	"history.empty":         "📜 The history is empty. Your recent downloads will appear here.",
+	"history.header":        "📜 Recent downloads (page %d/%d):\n",
+	"history.footer":        "\nTap a number to get the file again.",
+	"history.chapters":      "📑 %s by chapters",
+	"history.fragment":      ", fragment %s",
+	"history.not_found":     "❌ The entry wasn't found in the history.",
+	"history.redownloading": "⏳ Downloading again: %s",
+
+	// Access and subscription
+	"access.banned":              "🚫 You are banned and can't use the bot.",
+	"access.denied":              "🔒 Your access to the bot is restricted.",
+	"access.allowlist":           "🔒 This is a private bot.\n\nTo get access, send your ID to the administrator: %d",
+	"access.invite_only":         "🔒 The bot is invite-only.\n\nOpen an invite link or send /start <code>.",
+	"access.invite_check_failed": "❌ Failed to check the invite, please try again later.",
+	"access.invite_accepted":     "✅ Invite accepted, welcome!",
+	"access.invite_invalid":      "❌ The invite code is invalid, expired or already used.",
+	"sub.required":               "📢 Subscribe to our channel to download.\n\nThen tap \"Check again\".",
+	"sub.not_found":              "❌ Subscription not found. Subscribe to the channel and try again.",
+	"sub.confirmed":              "✅ Subscription confirmed",
+	"sub.thanks":                 "✅ Thanks for subscribing! Send the link again.",
+
+	// Notifications
+	"donor.granted":          "💎 You have been granted donor status %s.\nThank you for your support! Your downloads are processed with priority.",
+	"admin.inline_cancelled": "❌ The download was cancelled by an administrator.",
+	"admin.jobs_cancelled":   "❌ Your queued downloads were cancelled by an administrator.",
+
+	// Administration
+	"admin.help":               "🛠 Admin commands:\n\n/stats — overall statistics\n/queue — queued jobs\n/drop <job_id> — remove a job from the queue\n/prio <job_id> high|low — change a job's priority\n/flush — clear the queue\n/workers — worker status\n/ban <chat_id>, /unban <chat_id>, /banned — bans\n/access — access mode and lists, /allow, /disallow, /deny, /undeny <chat_id>\n/invite [uses] [days], /invites, /uninvite <code> — invites\n/grant, /revoke, /donors — donors\n/broadcast <text> — message all users (or reply to a message)",
+	"admin.grant_usage":        "Usage: /grant <chat_id> [days|forever] [basic|premium]\nDefault: 30 days, basic",
+	"admin.grant_failed":       "❌ Failed to grant the status: %s",
+	"admin.revoke_usage":       "Usage: /revoke <chat_id>",
+	"admin.revoke_static":      "⚠️ This donor is set in DONOR_CHAT_IDS, remove it from there and restart the bot.",
+	"admin.revoked_static":     "✅ The granted status of %d revoked, the basic status from DONOR_CHAT_IDS stays.",
+	"admin.revoke_failed":      "❌ Failed to revoke the status: %s",
+	"admin.not_donor":          "ℹ️ %d is not a donor.",
+	"admin.revoked":            "✅ Donor status of %d revoked.",
+	"admin.list_failed":        "❌ Failed to get the list: %s",
+	"admin.no_donors":          "💎 No donors yet.",
+	"admin.donors":             "💎 Donors (%d):\n",
+	"admin.queue_failed":       "❌ Failed to read the queue: %s",
+	"admin.stats":              "📈 Statistics\n\n👤 Users: %d\n💎 Donors: %d\n🚫 Banned: %d\n\n📋 Queued: %d (priority: %d)\n⚙️ Busy workers: %d of %d\n⏱ Uptime: %s",
+	"admin.overheated":         "\n\n🔥 Overheated: new jobs are rejected",
+	"admin.queue_empty":        "📋 The queue is empty.",
+	"admin.queue":              "📋 Queued: %d\n",
+	"admin.queue_more":         "\n… and %d more",
+	"admin.job_age":            "%s ago",
+	"admin.drop_usage":         "Usage: /drop <job_id>",
+	"admin.drop_failed":        "❌ Failed to remove the job: %s",
+	"admin.job_not_queued":     "ℹ️ The job isn't queued: it's already running or finished.",
+	"admin.job_dropped":        "✅ Job removed from the queue:\n%s",
+	"admin.prio_usage":         "Usage: /prio <job_id> high|low",
+	"admin.prio_failed":        "❌ Failed to change the priority: %s",
+	"admin.prio_changed":       "✅ Priority changed, the job is at the end of the queue:\n%s",
+	"admin.flush_failed":       "❌ Failed to clear the queue: %s",
+	"admin.flush_empty":        "📋 The queue is already empty.",
+	"admin.flushed":            "✅ Jobs removed from the queue: %d",
+	"admin.no_workers":         "⚙️ No workers are running.",
+	"admin.workers":            "⚙️ Workers (%d):\n",
+	"admin.worker_idle":        "\n#%d 💤 idle for %s",
+	"admin.ban_usage":          "Usage: /ban <chat_id>",
+	"admin.ban_admin":          "⚠️ Admins can't be banned.",
+	"admin.ban_failed":         "❌ Failed to ban: %s",
+	"admin.already_banned":     "ℹ️ %d is already banned.",
+	"admin.banned":             "✅ %d banned, jobs removed from the queue: %d",
+	"admin.unban_usage":        "Usage: /unban <chat_id>",
+	"admin.unban_failed":       "❌ Failed to unban: %s",
+	"admin.not_banned":         "ℹ️ %d is not banned.",
+	"admin.unbanned":           "✅ %d unbanned.",
+	"admin.no_banned":          "🚫 Nobody is banned.",
+	"admin.banned_list":        "🚫 Banned (%d):\n",
+	"admin.access_mode":        "🔐 Access mode: %s\n",
+	"admin.allowed":            "✅ Allowed",
+	"admin.denied":             "⛔ Denied",
+	"admin.lists_failed":       "❌ Failed to get the lists: %s",
+	"admin.list_inactive":      ", not used in this mode",
+	"admin.access_commands":    "\nCommands: /allow, /disallow, /deny, /undeny <chat_id>, /invite, /invites, /uninvite <code>",
+	"admin.list_usage":         "Usage: /%s <chat_id>\nUser and group IDs both work.",
+	"admin.list_change_failed": "❌ Failed to change the list: %s",
+	"admin.allowlist":          "allowlist",
+	"admin.denylist":           "denylist",
+	"admin.list_added":         "✅ %d added to the %s.",
+	"admin.list_already":       "ℹ️ %d is already in the %s.",
+	"admin.list_removed":       "✅ %d removed from the %s.",
+	"admin.list_missing":       "ℹ️ %d is not in the %s.",
+	"admin.list_unused":        "\n⚠️ This list is not used in the %s mode.",
+	"admin.invite_usage":       "Usage: /invite [uses] [days]\nDefault: 1 use, 7 days",
+	"admin.invite_failed":      "❌ Failed to create the invite: %s",
+	"admin.invite_created":     "🎟 Invite for %d use(s), valid for %d day(s):\n\nhttps://t.me/%s?start=%s\n\nOr the code: /start %s",
+	"admin.invite_mode":        "\n\n⚠️ Codes are only accepted in the invite mode, the current mode is %s.",
+	"admin.invites_failed":     "❌ Failed to get the invites: %s",
+	"admin.no_invites":         "🎟 No active invites.",
+	"admin.invites":            "🎟 Invites (%d):\n",
+	"admin.invite_line":        "\n%s — %d left, expires in %s",
+	"admin.uninvite_usage":     "Usage: /uninvite <code>",
+	"admin.uninvite_failed":    "❌ Failed to revoke the invite: %s",
+	"admin.invite_unknown":     "ℹ️ There is no such invite.",
+	"admin.invite_revoked":     "✅ Invite revoked.",
+	"admin.broadcast_usage":    "Usage: /broadcast <text>\nOr reply with /broadcast to the message to send.",
+	"admin.users_failed":       "❌ Failed to get the user list: %s",
+	"admin.no_users":           "ℹ️ No users yet.",
+	"admin.broadcast_busy":     "⏳ The previous broadcast hasn't finished yet.",
+	"admin.broadcast_started":  "📣 Broadcast started: %d recipients, about %s.",
+	"admin.broadcast_done":     "📣 Broadcast finished.\n\n✅ Delivered: %d\n🚫 Unreachable: %d",
+	"admin.broadcast_errors":   "\n❌ Errors: %d",
+}
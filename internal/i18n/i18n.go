@@ -0,0 +1,56 @@
+// Package i18n holds the bot's message catalogs. Russian is the source
+// language: every message exists in it, other languages fall back to it.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported languages
+const (
+	RU = "ru"
+	EN = "en"
+)
+
+// Languages lists the supported languages in the order they are offered
+var Languages = []string{RU, EN}
+
+var catalogs = map[string]map[string]string{
+	RU: ru,
+	EN: en,
+}
+
+// Supported reports whether there is a catalog for the language
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Match picks the supported language for a code like "en-US" or "en_US.UTF-8".
+// Unsupported and empty codes get the fallback.
+func Match(code, fallback string) string {
+	lang := strings.ToLower(code)
+	if i := strings.IndexAny(lang, "-_."); i >= 0 {
+		lang = lang[:i]
+	}
+	if Supported(lang) {
+		return lang
+	}
+	return fallback
+}
+
+// T returns the message in the language, formatted with args if there are
+// any. Unknown languages use Russian, unknown keys are returned as is.
+func T(lang, key string, args ...interface{}) string {
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		if msg, ok = ru[key]; !ok {
+			msg = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
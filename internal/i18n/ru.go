@@ -0,0 +1,282 @@
+package i18n
+
+var ru = map[string]string{
+	// Downloads
+	"exec.overheated":          "Система перегружена (высокая температура). Попробуйте позже.",
+	"exec.low_memory":          "Недостаточно памяти на устройстве. Попробуйте позже.",
+	"exec.download_failed":     "❌ Ошибка при скачивании.\n\nВозможные причины:\n• Неверная ссылка\n• Видео недоступно\n• Проблемы с сетью\n• Недостаточно памяти\n\nПопробуйте другую ссылку или повторите позже.",
+	"exec.sponsorblock_cut":    "✂️ SponsorBlock: вырезано %s (реклама, интро, самопиар)",
+	"exec.what_voice":          "голосового сообщения",
+	"exec.what_video_note":     "видеосообщения",
+	"exec.too_long":            "❌ Слишком длинно для %s: %d сек. (максимум %d сек.)\n\nУкажите фрагмент после ссылки, например: ссылка 0:00-0:%02d",
+	"exec.send_failed":         "❌ Ошибка при отправке %s.\n\nПопробуйте другую ссылку или повторите позже.",
+	"exec.gif_failed":          "❌ Ошибка при создании GIF.\n\nПопробуйте другую ссылку или повторите позже.",
+	"exec.chapters_failed":     "❌ Ошибка при отправке глав.\n\nВозможно, файлы слишком большие.\nПопробуйте скачать аудио целиком.",
+	"exec.no_chapters":         "📑 В этом видео нет глав, отправляю аудио целиком.",
+	"exec.audio_failed":        "❌ Ошибка при отправке аудио.\n\nВозможно, файл слишком большой или поврежден.\nПопробуйте другую ссылку.",
+	"exec.video_failed":        "❌ Ошибка при отправке видео.",
+	"exec.video_failed_config": "Проблема с конфигурацией бота. Обратитесь к администратору.",
+	"exec.video_failed_file":   "Возможно, файл слишком большой или поврежден.\nПопробуйте другую ссылку.",
+	"exec.video_failed_retry":  "Попробуйте повторить запрос позже.",
+	"exec.subs_not_found":      "💬 Субтитры на языке «%s» не найдены, видео будет отправлено без них.",
+	"exec.subs_burn_failed":    "💬 Не удалось наложить субтитры, видео будет отправлено без них.",
+	"inline.send_failed":       "❌ Не удалось отправить файл в чат.",
+	"duration.min_sec":         "%d мин %d сек",
+	"duration.sec":             "%d сек",
+
+	// Live streams
+	"live.offer":              "🔴 Это прямая трансляция.\n\nЗаписать с текущего момента? Максимум: %d мин.",
+	"live.button":             "⏺ %d мин",
+	"live.cancel":             "❌ Отмена",
+	"live.unavailable":        "❌ Это прямая трансляция, но запись сейчас недоступна. Попробуйте позже.",
+	"live.inline_unavailable": "🔴 Запись трансляций недоступна в инлайн-режиме. Отправьте ссылку боту в личные сообщения.",
+
+	// Configuration
+	"config.bot_token":             "BOT_TOKEN is required\n\nПожалуйста, установите переменную окружения BOT_TOKEN:\n  export BOT_TOKEN=\"your_token_here\"\n\nИли создайте файл .env со следующим содержимым:\n  BOT_TOKEN=your_token_here\n\nПолучить токен можно у @BotFather в Telegram:\n  1. Откройте https://t.me/BotFather\n  2. Отправьте команду /newbot\n  3. Следуйте инструкциям\n  4. Скопируйте полученный токен\n\nПодробнее см. QUICKSTART.md или README.md",
+	"config.default_language":      "DEFAULT_LANGUAGE=%q не поддерживается\n\nДопустимые значения: %s",
+	"config.access_mode":           "ACCESS_MODE=%q не поддерживается\n\nДопустимые значения:\n  open      - бот доступен всем (по умолчанию)\n  allowlist - только пользователям и чатам из списка\n  denylist  - всем, кроме пользователей и чатов из списка\n  invite    - по кодам приглашений\n\nПодробнее см. docs/CONFIGURATION.md",
+	"config.required_channel":      "REQUIRED_CHANNEL=%q не поддерживается\n\nУкажите имя канала (@channel) или его числовой ID (-1001234567890)",
+	"config.required_channel_link": "REQUIRED_CHANNEL_LINK is required for REQUIRED_CHANNEL=%s\n\nДля канала, заданного числовым ID, укажите ссылку для кнопки подписки:\n  REQUIRED_CHANNEL_LINK=https://t.me/+invite_hash\n\nИли задайте канал по имени: REQUIRED_CHANNEL=@channel",
+	"config.error":                 "❌ Ошибка конфигурации:\n%v\n\nПроверьте настройки и попробуйте снова.",
+
+	// Keyboards
+	"kb.quality":          "⚙️ Качество",
+	"kb.media":            "🎵 Аудио/Видео",
+	"kb.audio":            "🎧 Формат аудио",
+	"kb.delivery":         "📨 Отправка",
+	"kb.subs":             "💬 Субтитры",
+	"kb.status":           "📊 Статус",
+	"kb.language":         "🌐 Язык / Language",
+	"kb.back":             "◀️ Назад",
+	"kb.subs_off":         "🚫 Выкл",
+	"kb.subs_file":        "📄 Файлом",
+	"kb.subs_burn":        "🔥 Вшить",
+	"kb.subs_auto_on":     "🤖 Автосубтитры: вкл",
+	"kb.subs_auto_off":    "🤖 Автосубтитры: выкл",
+	"kb.voice_on":         "🎤 Аудио как голосовое: вкл",
+	"kb.voice_off":        "🎤 Аудио как голосовое: выкл",
+	"kb.round_on":         "⭕ Видео как кружок: вкл",
+	"kb.round_off":        "⭕ Видео как кружок: выкл",
+	"kb.sponsorblock_on":  "⏭ SponsorBlock (YouTube): вкл",
+	"kb.sponsorblock_off": "⏭ SponsorBlock (YouTube): выкл",
+	"kb.tags_on":          "🏷 Теги и обложка: вкл",
+	"kb.tags_off":         "🏷 Теги и обложка: выкл",
+	"kb.group_auto_on":    "🤖 Все ссылки: вкл",
+	"kb.group_auto_off":   "🤖 Все ссылки: выкл",
+	"kb.best":             "🏆 Лучшее",
+	"kb.audio_only":       "🎵 Аудио",
+	"kb.video_only":       "🎬 Видео",
+	"kb.bitrate_best":     "🏆 Макс.",
+	"kb.chapters":         "📑 Аудио по главам",
+	"kb.subscribe":        "📢 Подписаться",
+	"kb.check_again":      "🔄 Проверить снова",
+	"kb.newer":            "◀️ Новее",
+	"kb.older":            "Старее ▶️",
+
+	// Language selection
+	"lang.name":   "🇷🇺 Русский",
+	"lang.auto":   "🌐 Авто (язык Telegram)",
+	"lang.choose": "🌐 Язык: %s\n\nВыбери язык бота. «Авто» — язык твоего Telegram.",
+	"lang.set":    "✅ Язык: %s",
+
+	// Commands and menus
+	"cmd.start":             "Привет! Я бот для скачивания видео.\n\n📥 Отправь ссылку на видео для скачивания\n🔍 Или просто напиши, что найти (sc <запрос> — поиск в SoundCloud)\n\nИспользуй кнопки ниже для настройки:",
+	"cmd.start_group":       "\n\n👥 В группе упомяни меня или ответь на моё сообщение со ссылкой. Настройки меняют только админы.",
+	"cmd.help":              "📋 Справка:\n\n💡 Просто отправь ссылку на видео для скачивания!\n\nИспользуй кнопки для настройки качества и типа медиа.\n\n⚙️ Качество - выбери разрешение видео\n🎵 Аудио/Видео - выбери тип скачивания\n📊 Статус - посмотри очередь и настройки\n📜 /history - последние скачивания\n🌐 /lang - язык бота\n\n💎 /donate - приоритетная обработка за звёзды Telegram",
+	"cmd.use_buttons":       "Используй кнопки для настройки:",
+	"cmd.unknown":           "❌ Неизвестная команда. Используйте /help",
+	"menu.main":             "Главное меню:",
+	"menu.quality":          "⚙️ Выбери качество:\n\nТекущее: %s",
+	"menu.quality_set":      "✅ Качество установлено: %s (%s)",
+	"menu.media":            "🎵 Выбери тип:\n\nТекущий: %s",
+	"menu.media_audio_set":  "✅ Режим установлен: скачивание аудио (%s)\n\nТеперь отправь ссылку на видео.",
+	"menu.media_video_set":  "✅ Режим установлен: скачивание видео\nКачество: %s",
+	"menu.audio":            "🎧 Выбери формат и битрейт аудио:\n\nТекущие: %s",
+	"menu.audio_set":        "✅ Аудио: %s",
+	"menu.delivery":         "📨 Как отправлять файлы:\n\n🎤 Голосовое — аудио до %d сек. в формате OGG/Opus\n⭕ Кружок — квадратное видео до %d сек.",
+	"menu.subs":             "💬 Субтитры: %s\n\n📄 Файлом — .srt отдельным документом\n🔥 Вшить — субтитры поверх видео (дольше обработка)",
+	"menu.group_auto":       "Главное меню:\n\n🤖 Все ссылки: бот скачивает любые ссылки в группе, а не только с упоминанием",
+	"menu.sponsorblock_on":  "✅ SponsorBlock включен: реклама, интро и самопиар будут вырезаны из видео YouTube",
+	"menu.sponsorblock_off": "✅ SponsorBlock выключен",
+	"media.video":           "видео",
+	"media.audio":           "аудио",
+	"status":                "📊 Очередь: %d задач\n\n⚙️ Текущие настройки:\nКачество: %s\nТип: %s\nАудио: %s\nСубтитры: %s",
+	"prefs.unavailable":     "❌ Система предпочтений недоступна",
+	"prefs.save_failed":     "❌ Ошибка при сохранении настроек",
+	"prefs.bitrate_best":    "макс. качество",
+	"prefs.subs_file":       "файлом, %s",
+	"prefs.subs_burn":       "вшитые, %s",
+	"prefs.subs_off":        "выкл",
+	"group.admins_only":     "⛔ Настройки группы могут менять только администраторы.",
+
+	// Links and queue
+	"url.no_links":              "❌ В сообщении не найдено ссылок на видео.",
+	"url.bad_range":             "❌ Неверный интервал. Пример: ссылка 1:20-2:05",
+	"url.save_failed":           "❌ Ошибка при обработке ссылки. Попробуйте позже.",
+	"url.choose_quality":        "📥 Выбери качество для скачивания:",
+	"url.fragment":              "\n✂️ Фрагмент: %s",
+	"url.queued_many":           "📥 Добавлено в очередь: %d из %d ссылок.\nФайлы придут с настройками по умолчанию.",
+	"url.skipped":               "\n\n⚠️ За раз обрабатывается не больше %d ссылок, остальные %d пропущены.",
+	"clip.to_end":               "%s – конец",
+	"queue.enqueue_failed":      "❌ Ошибка при добавлении задачи в очередь. Попробуйте позже.",
+	"queue.enqueue_many_failed": "❌ Ошибка при добавлении задач в очередь. Попробуйте позже.",
+	"callback.bad_request":      "❌ Ошибка: неверный формат запроса",
+	"callback.unavailable":      "❌ Система недоступна. Попробуйте отправить ссылку снова.",
+	"callback.link_expired":     "❌ Ссылка устарела или не найдена. Пожалуйста, отправьте ссылку снова.",
+	"callback.bad_link":         "❌ Неверная ссылка",
+	"callback.request_expired":  "❌ Запрос устарел. Пожалуйста, отправьте ссылку снова.",
+	"live.too_long":             "❌ Максимальная длительность записи: %d мин.",
+	"live.recording":            "⏺ Трансляция поставлена на запись: %d мин. Файл придёт после окончания.",
+
+	// Limits
+	"limit.check_failed":  "❌ Ошибка проверки лимитов. Попробуйте позже.",
+	"limit.requests":      "⏳ Слишком много запросов (лимит: %d в минуту).\nПопробуйте через %s.",
+	"limit.jobs":          "📊 Дневной лимит скачиваний исчерпан (%d).\nСброс через %s.",
+	"limit.bytes":         "📊 Дневной лимит трафика исчерпан (%d МБ).\nСброс через %s.",
+	"limit.donors_higher": "\n\n💎 У доноров лимиты выше.",
+	"wait.hours":          "%d ч %d мин",
+	"wait.minutes":        "%d мин",
+
+	// Search and inline mode
+	"search.too_short":         "Пожалуйста, отправьте ссылку на видео или поисковый запрос.",
+	"search.unavailable":       "❌ Поиск сейчас недоступен. Отправьте ссылку на видео.",
+	"search.searching":         "🔍 Ищу: %s",
+	"search.failed":            "❌ Ошибка поиска. Попробуйте позже.",
+	"search.nothing":           "🤷 Ничего не найдено по запросу: %s",
+	"search.results":           "🔍 Результаты по запросу: %s\n\nВыбери, что скачать:",
+	"search.retry_unavailable": "❌ Система недоступна. Попробуйте поискать снова.",
+	"search.expired":           "❌ Результаты поиска устарели. Пожалуйста, повторите поиск.",
+	"search.queued":            "📥 Добавлено в очередь: %s",
+	"inline.unavailable":       "Инлайн-режим недоступен, откройте бота",
+	"inline.subscribe":         "Подпишитесь на канал, чтобы скачивать",
+	"inline.download":          "📥 Скачать",
+	"inline.hint":              "Введите ссылку или запрос",
+	"inline.loading":           "⏳ Загрузка: %s",
+	"inline.open":              "🔗 Открыть",
+	"inline.expired":           "❌ Запрос устарел. Попробуйте ещё раз.",
+
+	// Payments
+	"pay.unavailable":         "💎 Покупка приоритета сейчас недоступна. Обратитесь к администратору бота.",
+	"pay.private_only":        "💎 Купить приоритет можно в личных сообщениях с ботом.",
+	"pay.plans":               "💎 Приоритетный доступ\n\n• Ваши задачи обрабатываются первыми\n• Повышенные лимиты\n• Более длинная запись трансляций\n\nОплата звёздами Telegram ⭐",
+	"pay.active":              "\n\nСейчас приоритет активен %s, покупка продлит его.",
+	"pay.plan_button":         "%d дн. — %d ⭐",
+	"pay.plan_gone":           "❌ Этот тариф больше недоступен. Используйте /donate",
+	"pay.invoice_title":       "Приоритет на %d дн.",
+	"pay.invoice_description": "Задачи обрабатываются первыми, лимиты выше.",
+	"pay.invoice_label":       "%d дн.",
+	"pay.invoice_failed":      "❌ Не удалось создать счёт. Попробуйте позже.",
+	"pay.plan_changed":        "Тариф изменился. Пожалуйста, запросите новый счёт через /donate.",
+	"pay.plan_missing":        "⚠️ Оплата получена, но тариф не найден. Обратитесь к администратору.",
+	"pay.grant_failed":        "⚠️ Оплата получена, но не удалось включить приоритет. Обратитесь к администратору.",
+	"pay.thanks":              "💎 Спасибо за поддержку! Приоритет активен %s.",
+	"donor.forever":           "навсегда",
+	"donor.until":             "до %s",
+
+	// History
+	"history.load_failed":   "❌ Не удалось загрузить историю. Попробуйте позже.",
+	"history.empty":         "📜 История пуста. Здесь появятся последние скачанные файлы.",
+	"history.header":        "📜 Последние скачивания (стр. %d/%d):\n",
+	"history.footer":        "\nНажмите на номер, чтобы получить файл снова.",
+	"history.chapters":      "📑 %s по главам",
+	"history.fragment":      ", фрагмент %s",
+	"history.not_found":     "❌ Запись не найдена в истории.",
+	"history.redownloading": "⏳ Скачиваю заново: %s",
+
+	// Access and subscription
+	"access.banned":              "🚫 Вы заблокированы и не можете пользоваться ботом.",
+	"access.denied":              "🔒 Доступ к боту для вас ограничен.",
+	"access.allowlist":           "🔒 Это закрытый бот.\n\nЧтобы получить доступ, передайте администратору ваш ID: %d",
+	"access.invite_only":         "🔒 Бот доступен по приглашениям.\n\nОткройте ссылку-приглашение или отправьте /start <код>.",
+	"access.invite_check_failed": "❌ Не удалось проверить приглашение, попробуйте позже.",
+	"access.invite_accepted":     "✅ Приглашение принято, добро пожаловать!",
+	"access.invite_invalid":      "❌ Код приглашения недействителен, истёк или уже использован.",
+	"sub.required":               "📢 Чтобы скачивать, подпишитесь на наш канал.\n\nПосле подписки нажмите «Проверить снова».",
+	"sub.not_found":              "❌ Подписка не найдена. Подпишитесь на канал и попробуйте ещё раз.",
+	"sub.confirmed":              "✅ Подписка подтверждена",
+	"sub.thanks":                 "✅ Спасибо за подписку! Отправьте ссылку ещё раз.",
+
+	// Notifications
+	"donor.granted":          "💎 Вам выдан статус донора %s.\nСпасибо за поддержку! Ваши задачи обрабатываются в приоритете.",
+	"admin.inline_cancelled": "❌ Загрузка отменена администратором.",
+	"admin.jobs_cancelled":   "❌ Ваши задачи в очереди отменены администратором.",
+
+	// Administration
+	"admin.help":               "🛠 Команды администратора:\n\n/stats — общая статистика\n/queue — задачи в очереди\n/drop <job_id> — убрать задачу из очереди\n/prio <job_id> high|low — изменить приоритет задачи\n/flush — очистить очередь\n/workers — состояние воркеров\n/ban <chat_id>, /unban <chat_id>, /banned — блокировки\n/access — режим доступа и списки, /allow, /disallow, /deny, /undeny <chat_id>\n/invite [использований] [дни], /invites, /uninvite <код> — приглашения\n/grant, /revoke, /donors — доноры\n/broadcast <текст> — рассылка всем пользователям (или ответом на сообщение)",
+	"admin.grant_usage":        "Использование: /grant <chat_id> [дни|forever] [basic|premium]\nПо умолчанию: 30 дней, basic",
+	"admin.grant_failed":       "❌ Не удалось выдать статус: %s",
+	"admin.revoke_usage":       "Использование: /revoke <chat_id>",
+	"admin.revoke_static":      "⚠️ Этот донор задан в DONOR_CHAT_IDS, уберите его оттуда и перезапустите бота.",
+	"admin.revoked_static":     "✅ Выданный статус у %d отозван, базовый статус из DONOR_CHAT_IDS остаётся.",
+	"admin.revoke_failed":      "❌ Не удалось отозвать статус: %s",
+	"admin.not_donor":          "ℹ️ %d не является донором.",
+	"admin.revoked":            "✅ Статус донора у %d отозван.",
+	"admin.list_failed":        "❌ Не удалось получить список: %s",
+	"admin.no_donors":          "💎 Доноров пока нет.",
+	"admin.donors":             "💎 Доноры (%d):\n",
+	"admin.queue_failed":       "❌ Не удалось прочитать очередь: %s",
+	"admin.stats":              "📈 Статистика\n\n👤 Пользователей: %d\n💎 Доноров: %d\n🚫 Заблокировано: %d\n\n📋 В очереди: %d (приоритетных: %d)\n⚙️ Воркеров занято: %d из %d\n⏱ Аптайм: %s",
+	"admin.overheated":         "\n\n🔥 Перегрев: новые задачи отклоняются",
+	"admin.queue_empty":        "📋 Очередь пуста.",
+	"admin.queue":              "📋 В очереди: %d\n",
+	"admin.queue_more":         "\n… и ещё %d",
+	"admin.job_age":            "%s назад",
+	"admin.drop_usage":         "Использование: /drop <job_id>",
+	"admin.drop_failed":        "❌ Не удалось убрать задачу: %s",
+	"admin.job_not_queued":     "ℹ️ Задачи нет в очереди: она уже выполняется или завершена.",
+	"admin.job_dropped":        "✅ Задача убрана из очереди:\n%s",
+	"admin.prio_usage":         "Использование: /prio <job_id> high|low",
+	"admin.prio_failed":        "❌ Не удалось изменить приоритет: %s",
+	"admin.prio_changed":       "✅ Приоритет изменён, задача в конце очереди:\n%s",
+	"admin.flush_failed":       "❌ Не удалось очистить очередь: %s",
+	"admin.flush_empty":        "📋 Очередь и так пуста.",
+	"admin.flushed":            "✅ Из очереди убрано задач: %d",
+	"admin.no_workers":         "⚙️ Воркеры не запущены.",
+	"admin.workers":            "⚙️ Воркеры (%d):\n",
+	"admin.worker_idle":        "\n#%d 💤 свободен %s",
+	"admin.ban_usage":          "Использование: /ban <chat_id>",
+	"admin.ban_admin":          "⚠️ Администратора нельзя заблокировать.",
+	"admin.ban_failed":         "❌ Не удалось заблокировать: %s",
+	"admin.already_banned":     "ℹ️ %d уже заблокирован.",
+	"admin.banned":             "✅ %d заблокирован, задач убрано из очереди: %d",
+	"admin.unban_usage":        "Использование: /unban <chat_id>",
+	"admin.unban_failed":       "❌ Не удалось разблокировать: %s",
+	"admin.not_banned":         "ℹ️ %d не заблокирован.",
+	"admin.unbanned":           "✅ %d разблокирован.",
+	"admin.no_banned":          "🚫 Заблокированных нет.",
+	"admin.banned_list":        "🚫 Заблокированы (%d):\n",
+	"admin.access_mode":        "🔐 Режим доступа: %s\n",
+	"admin.allowed":            "✅ Разрешены",
+	"admin.denied":             "⛔ Запрещены",
+	"admin.lists_failed":       "❌ Не удалось получить списки: %s",
+	"admin.list_inactive":      ", не действует в этом режиме",
+	"admin.access_commands":    "\nКоманды: /allow, /disallow, /deny, /undeny <chat_id>, /invite, /invites, /uninvite <код>",
+	"admin.list_usage":         "Использование: /%s <chat_id>\nПодходят ID пользователей и групп.",
+	"admin.list_change_failed": "❌ Не удалось изменить список: %s",
+	"admin.allowlist":          "разрешённых",
+	"admin.denylist":           "запрещённых",
+	"admin.list_added":         "✅ %d добавлен в список %s.",
+	"admin.list_already":       "ℹ️ %d уже в списке %s.",
+	"admin.list_removed":       "✅ %d убран из списка %s.",
+	"admin.list_missing":       "ℹ️ %d нет в списке %s.",
+	"admin.list_unused":        "\n⚠️ В режиме %s этот список не действует.",
+	"admin.invite_usage":       "Использование: /invite [использований] [дни]\nПо умолчанию: 1 использование, 7 дней",
+	"admin.invite_failed":      "❌ Не удалось создать приглашение: %s",
+	"admin.invite_created":     "🎟 Приглашение на %d использ., действует %d дн.:\n\nhttps://t.me/%s?start=%s\n\nИли код: /start %s",
+	"admin.invite_mode":        "\n\n⚠️ Коды принимаются только в режиме invite, сейчас %s.",
+	"admin.invites_failed":     "❌ Не удалось получить приглашения: %s",
+	"admin.no_invites":         "🎟 Активных приглашений нет.",
+	"admin.invites":            "🎟 Приглашения (%d):\n",
+	"admin.invite_line":        "\n%s — осталось %d, истекает через %s",
+	"admin.uninvite_usage":     "Использование: /uninvite <код>",
+	"admin.uninvite_failed":    "❌ Не удалось отозвать приглашение: %s",
+	"admin.invite_unknown":     "ℹ️ Такого приглашения нет.",
+	"admin.invite_revoked":     "✅ Приглашение отозвано.",
+	"admin.broadcast_usage":    "Использование: /broadcast <текст>\nИли ответьте командой /broadcast на сообщение, которое нужно разослать.",
+	"admin.users_failed":       "❌ Не удалось получить список пользователей: %s",
+	"admin.no_users":           "ℹ️ Пользователей пока нет.",
+	"admin.broadcast_busy":     "⏳ Предыдущая рассылка ещё не закончилась.",
+	"admin.broadcast_started":  "📣 Рассылка запущена: %d получателей, примерно %s.",
+	"admin.broadcast_done":     "📣 Рассылка завершена.\n\n✅ Доставлено: %d\n🚫 Недоступны: %d",
+	"admin.broadcast_errors":   "\n❌ Ошибки: %d",
+}
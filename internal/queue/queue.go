@@ -17,13 +17,68 @@ const (
 )
 
 type Job struct {
-	ID        string    `json:"id"`
-	URL       string    `json:"url"`
-	ChatID    int64     `json:"chat_id"`
-	Priority  Priority  `json:"priority"`
-	Quality   string    `json:"quality"`   // "best", "1080p", "720p", "480p", "360p", "audio"
-	MediaType string    `json:"media_type"` // "video" or "audio"
-	CreatedAt time.Time `json:"created_at"`
+	ID            string    `json:"id"`
+	URL           string    `json:"url"`
+	ChatID        int64     `json:"chat_id"`
+	Priority      Priority  `json:"priority"`
+	Quality       string    `json:"quality"`                  // "best", "1080p", "720p", "480p", "360p", "audio", "gif"
+	MediaType     string    `json:"media_type"`               // "video", "audio" or "animation"
+	AudioFormat   string    `json:"audio_format,omitempty"`   // "mp3", "m4a", "opus", "ogg", "flac"
+	AudioBitrate  string    `json:"audio_bitrate,omitempty"`  // kbit/s or "best"
+	EmbedTags     bool      `json:"embed_tags,omitempty"`     // Write tags and cover art into audio files
+	Delivery      string    `json:"delivery,omitempty"`       // "" (regular file), "voice" or "video_note"
+	SubMode       string    `json:"sub_mode,omitempty"`       // "" (no subtitles), "file" or "burn"
+	SubLang       string    `json:"sub_lang,omitempty"`       // Subtitle language code, e.g. "en"
+	SubAuto       bool      `json:"sub_auto,omitempty"`       // Fall back to auto-generated subtitles
+	SponsorBlock  bool      `json:"sponsorblock,omitempty"`   // Cut sponsor, intro and self-promo segments
+	Chapters      bool      `json:"chapters,omitempty"`       // Split audio into one file per chapter
+	RecordSeconds int       `json:"record_seconds,omitempty"` // Live streams: how long to record
+	ClipStart     float64   `json:"clip_start,omitempty"`     // Seconds from the beginning, 0 = from start
+	ClipEnd       float64   `json:"clip_end,omitempty"`       // Seconds from the beginning, 0 = until the end
+	InlineMessage string    `json:"inline_message,omitempty"` // Inline message to put the result into
+	UserID        int64     `json:"user_id,omitempty"`        // Inline mode: user charged for the traffic if ChatID is INLINE_CHAT_ID
+	ReplyTo       int       `json:"reply_to,omitempty"`       // Message the result replies to (group chats)
+	Lang          string    `json:"lang,omitempty"`           // Language of messages about the job, see i18n
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// QuotaChatID returns the chat whose daily traffic quota the job counts towards
+func (j *Job) QuotaChatID() int64 {
+	if j.UserID != 0 {
+		return j.UserID
+	}
+	return j.ChatID
+}
+
+// IsClipped reports whether only a fragment of the media was requested
+func (j *Job) IsClipped() bool {
+	return j.ClipStart > 0 || j.ClipEnd > 0
+}
+
+// CacheKey identifies the file this job produces, so it can be reused.
+// Empty for one-off results like fragments, recordings and chapters.
+func (j *Job) CacheKey() string {
+	if j.IsClipped() || j.RecordSeconds > 0 || j.Chapters || j.SubMode != "" {
+		return ""
+	}
+	variant := j.MediaType + ":" + j.Quality
+	if j.MediaType == "audio" {
+		variant += fmt.Sprintf(":%s:%s:%t", j.AudioFormat, j.AudioBitrate, j.EmbedTags)
+	}
+	if j.Delivery != "" {
+		variant += ":" + j.Delivery
+	}
+	if j.SponsorBlock {
+		variant += ":sb"
+	}
+	return j.URL + "|" + variant
+}
+
+// CachedFile is a file already uploaded to Telegram
+type CachedFile struct {
+	FileID string `json:"file_id"`
+	Type   string `json:"type"` // "video", "audio", "voice", "video_note" or "animation"
+	Title  string `json:"title,omitempty"`
 }
 
 type Queue interface {
@@ -31,9 +86,28 @@ type Queue interface {
 	Dequeue(ctx context.Context) (*Job, error)
 	GetStatus() int
 	Close() error
-	GetClient() *redis.Client // For accessing Redis client for preferences
+	GetClient() *redis.Client   // For accessing Redis client for preferences
+	SavePending(job *Job) error // Park a job until the user confirms it
+	TakePending(id string) (*Job, error)
+	SaveFile(key string, file *CachedFile) error // Remember an uploaded file by Job.CacheKey
+	GetFile(key string) (*CachedFile, error)
+
+	// Administration
+	Jobs() ([]*Job, error)                                  // Queued jobs in processing order
+	Remove(id string) (*Job, error)                         // Drop a queued job, nil if it's gone
+	SetPriority(id string, priority Priority) (*Job, error) // Move a queued job, nil if it's gone
+	Flush() ([]*Job, error)                                 // Drop all queued jobs
 }
 
+// pendingJobTTL is how long a parked job waits for the user's answer
+const pendingJobTTL = 10 * time.Minute
+
+// fileCacheTTL is how long uploaded file IDs are reused
+const fileCacheTTL = 30 * 24 * time.Hour
+
+// queueNames lists the job lists in the order they are served
+var queueNames = []string{"queue:high", "queue:low"}
+
 type RedisQueue struct {
 	client *redis.Client
 	ctx    context.Context
@@ -73,12 +147,15 @@ func (q *RedisQueue) Enqueue(job *Job) error {
 		return fmt.Errorf("failed to marshal job: %w", err)
 	}
 
-	queueName := "queue:low"
-	if job.Priority == PriorityHigh {
-		queueName = "queue:high"
-	}
+	return q.client.LPush(q.ctx, queueName(job.Priority), data).Err()
+}
 
-	return q.client.LPush(q.ctx, queueName, data).Err()
+// queueName returns the Redis list holding jobs of the priority
+func queueName(priority Priority) string {
+	if priority == PriorityHigh {
+		return "queue:high"
+	}
+	return "queue:low"
 }
 
 // GetClient returns the underlying Redis client (for preferences storage)
@@ -88,10 +165,8 @@ func (q *RedisQueue) GetClient() *redis.Client {
 
 func (q *RedisQueue) Dequeue(ctx context.Context) (*Job, error) {
 	// First try high priority queue, then low priority
-	queues := []string{"queue:high", "queue:low"}
-
-	for _, queueName := range queues {
-		result, err := q.client.BRPop(ctx, 1*time.Second, queueName).Result()
+	for _, name := range queueNames {
+		result, err := q.client.BRPop(ctx, 1*time.Second, name).Result()
 		if err == redis.Nil {
 			continue
 		}
@@ -114,6 +189,34 @@ func (q *RedisQueue) Dequeue(ctx context.Context) (*Job, error) {
 	return nil, nil // No job available
 }
 
+// SavePending stores a job that needs user confirmation before it is enqueued
+func (q *RedisQueue) SavePending(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	return q.client.Set(q.ctx, "pending_job:"+job.ID, data, pendingJobTTL).Err()
+}
+
+// TakePending returns a parked job and removes it, so it can't be confirmed twice
+func (q *RedisQueue) TakePending(id string) (*Job, error) {
+	key := "pending_job:" + id
+	data, err := q.client.Get(q.ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	// Whoever deletes the key owns the job
+	if q.client.Del(q.ctx, key).Val() == 0 {
+		return nil, redis.Nil
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	return &job, nil
+}
+
 func (q *RedisQueue) GetStatus() int {
 	highCount := q.client.LLen(q.ctx, "queue:high").Val()
 	lowCount := q.client.LLen(q.ctx, "queue:low").Val()
@@ -123,3 +226,137 @@ func (q *RedisQueue) GetStatus() int {
 func (q *RedisQueue) Close() error {
 	return q.client.Close()
 }
+
+// SaveFile remembers an uploaded file so the same download can be served by file ID
+func (q *RedisQueue) SaveFile(key string, file *CachedFile) error {
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cached file: %w", err)
+	}
+	return q.client.Set(q.ctx, "file:"+key, data, fileCacheTTL).Err()
+}
+
+// GetFile returns a previously uploaded file for the cache key
+func (q *RedisQueue) GetFile(key string) (*CachedFile, error) {
+	data, err := q.client.Get(q.ctx, "file:"+key).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var file CachedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cached file: %w", err)
+	}
+	return &file, nil
+}
+
+// queuedJob is a job together with its raw list entry, which is needed to
+// remove exactly this entry with LREM
+type queuedJob struct {
+	job  *Job
+	list string
+	raw  string
+}
+
+// scan returns queued jobs in the order workers take them: high priority
+// first, oldest first. Entries that can't be decoded are skipped.
+func (q *RedisQueue) scan() ([]queuedJob, error) {
+	var jobs []queuedJob
+	for _, name := range queueNames {
+		entries, err := q.client.LRange(q.ctx, name, 0, -1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		// Jobs are pushed on the left and popped on the right
+		for i := len(entries) - 1; i >= 0; i-- {
+			var job Job
+			if err := json.Unmarshal([]byte(entries[i]), &job); err != nil {
+				continue
+			}
+			jobs = append(jobs, queuedJob{job: &job, list: name, raw: entries[i]})
+		}
+	}
+	return jobs, nil
+}
+
+// find returns the queued job with the given ID, nil if there is none
+func (q *RedisQueue) find(id string) (*queuedJob, error) {
+	jobs, err := q.scan()
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		if jobs[i].job.ID == id {
+			return &jobs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Jobs returns queued jobs in the order they will be processed
+func (q *RedisQueue) Jobs() ([]*Job, error) {
+	queued, err := q.scan()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, len(queued))
+	for i, qj := range queued {
+		jobs[i] = qj.job
+	}
+	return jobs, nil
+}
+
+// Remove drops a queued job. Returns nil if it was already taken by a worker.
+func (q *RedisQueue) Remove(id string) (*Job, error) {
+	qj, err := q.find(id)
+	if err != nil || qj == nil {
+		return nil, err
+	}
+	// Whoever removes the entry owns the job, same as with BRPOP
+	removed, err := q.client.LRem(q.ctx, qj.list, 1, qj.raw).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove job: %w", err)
+	}
+	if removed == 0 {
+		return nil, nil
+	}
+	return qj.job, nil
+}
+
+// SetPriority moves a queued job to the end of the other priority list.
+// Returns nil if the job was already taken by a worker.
+func (q *RedisQueue) SetPriority(id string, priority Priority) (*Job, error) {
+	job, err := q.Remove(id)
+	if err != nil || job == nil {
+		return nil, err
+	}
+	job.Priority = priority
+	if err := q.Enqueue(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Flush drops all queued jobs and returns them
+func (q *RedisQueue) Flush() ([]*Job, error) {
+	pipe := q.client.TxPipeline()
+	ranges := make([]*redis.StringSliceCmd, len(queueNames))
+	for i, name := range queueNames {
+		ranges[i] = pipe.LRange(q.ctx, name, 0, -1)
+		pipe.Del(q.ctx, name)
+	}
+	if _, err := pipe.Exec(q.ctx); err != nil {
+		return nil, fmt.Errorf("failed to flush queue: %w", err)
+	}
+
+	var jobs []*Job
+	for _, r := range ranges {
+		entries := r.Val()
+		for i := len(entries) - 1; i >= 0; i-- {
+			var job Job
+			if err := json.Unmarshal([]byte(entries[i]), &job); err == nil {
+				jobs = append(jobs, &job)
+			}
+		}
+	}
+	return jobs, nil
+}
@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Limits for a single chat. Zero means unlimited.
+type Limits struct {
+	RequestsPerMinute int
+	JobsPerDay        int
+	BytesPerDay       int64
+}
+
+// Limit kinds reported in LimitError
+const (
+	KindRequests = "requests"
+	KindJobs     = "jobs"
+	KindBytes    = "bytes"
+)
+
+// LimitError is returned when a chat ran out of one of its limits
+type LimitError struct {
+	Kind       string
+	Limit      int64
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit of %d exceeded, retry after %s", e.Kind, e.Limit, e.RetryAfter)
+}
+
+// tokenBucketScript refills the bucket for the time passed since the last
+// request and takes one token. Returns {allowed, milliseconds to wait}.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local data = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(data[1]) or capacity
+local ts = tonumber(data[2]) or now
+tokens = math.min(capacity, tokens + (now - ts) * rate)
+local allowed, wait = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) / rate)
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(capacity / rate) + 1000)
+return {allowed, wait}
+`)
+
+// Limiter keeps per-chat usage in Redis so limits hold across restarts
+// and are shared between the bot and the workers
+type Limiter struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+func New(client *redis.Client) *Limiter {
+	return &Limiter{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+// AllowRequest takes a token from the chat's per-minute bucket
+func (l *Limiter) AllowRequest(chatID int64, limits Limits) error {
+	if limits.RequestsPerMinute <= 0 {
+		return nil
+	}
+	wait, ok := l.takeToken(fmt.Sprintf("rate:requests:%d", chatID), limits.RequestsPerMinute)
+	if ok {
+		return nil
+	}
+	return &LimitError{
+		Kind:       KindRequests,
+		Limit:      int64(limits.RequestsPerMinute),
+		RetryAfter: wait,
+	}
+}
+
+// AllowSearch takes a token from the user's inline search bucket. Inline
+// searches run on every keystroke, so they have a bucket of their own
+// and don't eat into the request limit.
+func (l *Limiter) AllowSearch(userID int64, perMinute int) bool {
+	if perMinute <= 0 {
+		return true
+	}
+	_, ok := l.takeToken(fmt.Sprintf("rate:search:%d", userID), perMinute)
+	return ok
+}
+
+// takeToken takes a token from a bucket refilled at perMinute tokens a
+// minute. Returns how long to wait if the bucket is empty.
+func (l *Limiter) takeToken(key string, perMinute int) (time.Duration, bool) {
+	perMs := float64(perMinute) / float64(time.Minute/time.Millisecond)
+	res, err := tokenBucketScript.Run(l.ctx, l.client, []string{key},
+		perMinute, perMs, time.Now().UnixMilli()).Int64Slice()
+	if err != nil || len(res) != 2 {
+		return 0, true // Don't lock users out because of Redis errors
+	}
+	return time.Duration(res[1]) * time.Millisecond, res[0] == 1
+}
+
+// AllowJobs reserves n jobs from today's quota. Traffic is checked too,
+// it is only counted after delivery so it can't be reserved in advance.
+func (l *Limiter) AllowJobs(chatID int64, n int, limits Limits) error {
+	if limits.BytesPerDay > 0 {
+		used, _ := l.client.Get(l.ctx, dailyKey(KindBytes, chatID)).Int64()
+		if used >= limits.BytesPerDay {
+			return &LimitError{Kind: KindBytes, Limit: limits.BytesPerDay, RetryAfter: untilReset()}
+		}
+	}
+	if limits.JobsPerDay <= 0 {
+		return nil
+	}
+
+	key := dailyKey(KindJobs, chatID)
+	count, err := l.client.IncrBy(l.ctx, key, int64(n)).Result()
+	if err != nil {
+		return nil
+	}
+	l.client.Expire(l.ctx, key, untilReset())
+	if count > int64(limits.JobsPerDay) {
+		l.client.DecrBy(l.ctx, key, int64(n))
+		return &LimitError{Kind: KindJobs, Limit: int64(limits.JobsPerDay), RetryAfter: untilReset()}
+	}
+	return nil
+}
+
+// AddBytes counts delivered traffic towards today's quota
+func (l *Limiter) AddBytes(chatID int64, n int64) {
+	key := dailyKey(KindBytes, chatID)
+	l.client.IncrBy(l.ctx, key, n)
+	l.client.Expire(l.ctx, key, untilReset())
+}
+
+// Daily quotas reset at midnight UTC
+func dailyKey(kind string, chatID int64) string {
+	return fmt.Sprintf("rate:%s:%d:%s", kind, chatID, time.Now().UTC().Format("20060102"))
+}
+
+func untilReset() time.Duration {
+	now := time.Now().UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	return midnight.Sub(now)
+}
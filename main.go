@@ -12,6 +12,7 @@ import (
 	"envedour-bot/internal/bot"
 	"envedour-bot/internal/config"
 	"envedour-bot/internal/executor"
+	"envedour-bot/internal/i18n"
 	"envedour-bot/internal/queue"
 	"envedour-bot/internal/thermal"
 )
@@ -22,7 +23,9 @@ func main() {
 
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("❌ Ошибка конфигурации:\n%v\n\nПроверьте настройки и попробуйте снова.", err)
+		// .env is loaded by now, so DEFAULT_LANGUAGE from it applies too
+		lang := i18n.Match(os.Getenv("DEFAULT_LANGUAGE"), i18n.RU)
+		log.Fatal(i18n.T(lang, "config.error", err))
 	}
 
 	// Initialize thermal monitoring if ARM optimized
@@ -42,7 +45,7 @@ func main() {
 	log.Printf("✓ Redis connected")
 
 	// Initialize executor
-	exec := executor.NewExecutor(cfg, *armOptimized)
+	exec := executor.NewExecutor(cfg, redisQueue.GetClient(), *armOptimized)
 	defer exec.Close()
 
 	// Initialize bot